@@ -0,0 +1,228 @@
+package flutterbuild
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+// androidArtifactInfo is the package metadata read from the manifest of an APK or AAB.
+type androidArtifactInfo struct {
+	applicationID    string
+	versionCode      string
+	versionName      string
+	minSDKVersion    string
+	targetSDKVersion string
+	abis             []string
+}
+
+// androidArtifactExpectations are the expected package metadata values, empty fields are not verified.
+type androidArtifactExpectations struct {
+	applicationID string
+	versionName   string
+	versionCode   string
+}
+
+func (expected androidArtifactExpectations) isEmpty() bool {
+	return expected == androidArtifactExpectations{}
+}
+
+// splitAPKABIVersionCodes are the version code multipliers of Flutter's Gradle plugin, with --split-per-abi
+// the version code of a split APK is abiVersionCode*1000 + versionCode.
+var splitAPKABIVersionCodes = map[string]int{"armeabi-v7a": 1, "arm64-v8a": 2, "x86": 3, "x86_64": 4}
+
+// forSplitAPK returns the expectations of the split APK of the ABI.
+func (expected androidArtifactExpectations) forSplitAPK(abi string) androidArtifactExpectations {
+	abiVersionCode, ok := splitAPKABIVersionCodes[abi]
+	if !ok || expected.versionCode == "" {
+		return expected
+	}
+	versionCode, err := strconv.Atoi(expected.versionCode)
+	if err != nil {
+		return expected
+	}
+	expected.versionCode = strconv.Itoa(abiVersionCode*1000 + versionCode)
+	return expected
+}
+
+// inspectAndroidArtifact reads the package metadata from the binary XML manifest of an APK
+// or from the protobuf manifest of an AAB.
+func inspectAndroidArtifact(pth string) (androidArtifactInfo, error) {
+	reader, err := zip.OpenReader(pth)
+	if err != nil {
+		return androidArtifactInfo{}, fmt.Errorf("failed to open %s: %s", pth, err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", pth, err)
+		}
+	}()
+
+	isAppBundle := filepath.Ext(pth) == ".aab"
+	manifestPth := "AndroidManifest.xml"
+	if isAppBundle {
+		manifestPth = "base/manifest/AndroidManifest.xml"
+	}
+
+	var manifest []byte
+	var abis []string
+	for _, file := range reader.File {
+		if file.Name == manifestPth {
+			manifest, err = readZipFile(file)
+			if err != nil {
+				return androidArtifactInfo{}, err
+			}
+		}
+
+		if abi := nativeLibraryABI(file.Name, isAppBundle); abi != "" && !sliceutil.IsStringInSlice(abi, abis) {
+			abis = append(abis, abi)
+		}
+	}
+	if manifest == nil {
+		return androidArtifactInfo{}, fmt.Errorf("%s not found in %s", manifestPth, pth)
+	}
+
+	var elements []manifestElement
+	if isAppBundle {
+		elements, err = parseProtoManifest(manifest)
+	} else {
+		elements, err = parseBinaryXMLManifest(manifest)
+	}
+	if err != nil {
+		return androidArtifactInfo{}, fmt.Errorf("failed to parse the manifest of %s: %s", pth, err)
+	}
+
+	info := androidArtifactInfoFromManifest(elements)
+	sort.Strings(abis)
+	info.abis = abis
+	return info, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rc.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", file.Name, err)
+		}
+	}()
+	return io.ReadAll(rc)
+}
+
+// nativeLibraryABI returns the ABI of a native library path: lib/<abi>/*.so in APKs and <module>/lib/<abi>/*.so in AABs.
+func nativeLibraryABI(name string, isAppBundle bool) string {
+	parts := strings.Split(name, "/")
+	if isAppBundle {
+		if len(parts) < 4 {
+			return ""
+		}
+		parts = parts[1:]
+	}
+	if len(parts) < 3 || parts[0] != "lib" {
+		return ""
+	}
+	return parts[1]
+}
+
+func androidArtifactInfoFromManifest(elements []manifestElement) androidArtifactInfo {
+	var info androidArtifactInfo
+	for _, element := range elements {
+		switch {
+		case element.depth == 0 && element.name == "manifest":
+			info.applicationID = element.attrs["package"]
+			info.versionCode = element.attrs["versionCode"]
+			info.versionName = element.attrs["versionName"]
+		case element.depth == 1 && element.name == "uses-sdk":
+			info.minSDKVersion = element.attrs["minSdkVersion"]
+			info.targetSDKVersion = element.attrs["targetSdkVersion"]
+		}
+	}
+	return info
+}
+
+func (info androidArtifactInfo) print() {
+	log.Printf("  Application ID: %s", info.applicationID)
+	log.Printf("  Version name: %s", info.versionName)
+	log.Printf("  Version code: %s", info.versionCode)
+	log.Printf("  Min SDK version: %s", info.minSDKVersion)
+	log.Printf("  Target SDK version: %s", info.targetSDKVersion)
+	if len(info.abis) > 0 {
+		log.Printf("  ABIs: %s", strings.Join(info.abis, ", "))
+	} else {
+		log.Printf("  ABIs: no native libraries")
+	}
+}
+
+// verify returns an error listing the fields not matching the expected values.
+func (info androidArtifactInfo) verify(expected androidArtifactExpectations) error {
+	var mismatches []string
+	check := func(field, got, want string) {
+		if want != "" && got != want {
+			mismatches = append(mismatches, fmt.Sprintf("%s is %s, expected %s", field, got, want))
+		}
+	}
+	check("application ID", info.applicationID, expected.applicationID)
+	check("version name", info.versionName, expected.versionName)
+	check("version code", info.versionCode, expected.versionCode)
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
+
+func exportAndroidArtifactInfo(exporter OutputExporter, info androidArtifactInfo) error {
+	return exportOutputs(exporter, []envOutput{
+		{"FLUTTER_ANDROID_APPLICATION_ID", info.applicationID},
+		{"FLUTTER_ANDROID_VERSION_NAME", info.versionName},
+		{"FLUTTER_ANDROID_VERSION_CODE", info.versionCode},
+		{"FLUTTER_ANDROID_MIN_SDK_VERSION", info.minSDKVersion},
+		{"FLUTTER_ANDROID_TARGET_SDK_VERSION", info.targetSDKVersion},
+		{"FLUTTER_ANDROID_ABIS", strings.Join(info.abis, ",")},
+	})
+}
+
+// splitAPKABIs are the ABIs Flutter builds split APKs for with --split-per-abi.
+var splitAPKABIs = []string{"armeabi-v7a", "arm64-v8a", "x86_64", "x86"}
+
+// splitAPKABI returns the ABI of a split APK named like app-<abi>-<flavor>-<mode>.apk, or an empty string for other APKs.
+func splitAPKABI(pth string) string {
+	name := strings.TrimSuffix(filepath.Base(pth), filepath.Ext(pth))
+	for _, abi := range splitAPKABIs {
+		if strings.Contains("-"+name+"-", "-"+abi+"-") {
+			return abi
+		}
+	}
+	return ""
+}
+
+// splitAPKOutputEnvName returns the output name of a split APK, e.g. BITRISE_APK_PATH_ARM64_V8A.
+func splitAPKOutputEnvName(abi string) string {
+	return "BITRISE_APK_PATH_" + strings.ToUpper(strings.ReplaceAll(abi, "-", "_"))
+}
+
+// orderPrimaryABILast moves the split APKs of the primary ABI to the end of the list,
+// so that they are exported as the single file output.
+func orderPrimaryABILast(artifacts []string, primaryABI string) ([]string, error) {
+	var others, primary []string
+	for _, artifact := range artifacts {
+		if splitAPKABI(artifact) == primaryABI {
+			primary = append(primary, artifact)
+		} else {
+			others = append(others, artifact)
+		}
+	}
+	if len(primary) == 0 {
+		return nil, fmt.Errorf("no split APK found for the primary ABI (%s)", primaryABI)
+	}
+	return append(others, primary...), nil
+}
@@ -0,0 +1,297 @@
+package flutterbuild
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testAttribute struct {
+	name     uint32
+	dataType byte
+	data     uint32
+}
+
+// binaryXMLManifest encodes a minimal AXML manifest with a UTF-16 string pool and a resource map.
+func binaryXMLManifest() []byte {
+	return binaryXMLManifestWithVersionCode(42)
+}
+
+func binaryXMLManifestWithVersionCode(versionCode uint32) []byte {
+	stringPool := []string{
+		"", // versionCode, stripped name, resolved by the resource map
+		"versionName",
+		"minSdkVersion",
+		"targetSdkVersion",
+		"package",
+		"manifest",
+		"uses-sdk",
+		"io.bitrise.sample",
+		"1.2.3",
+	}
+	resourceMap := []uint32{0x0101021b, 0x0101021c, 0x0101020c, 0x01010270}
+
+	le := binary.LittleEndian
+	chunk := func(chunkType uint16, headerSize uint16, body []byte) []byte {
+		out := make([]byte, 8, 8+len(body))
+		le.PutUint16(out, chunkType)
+		le.PutUint16(out[2:], headerSize)
+		le.PutUint32(out[4:], uint32(8+len(body)))
+		return append(out, body...)
+	}
+
+	var stringData []byte
+	var offsets []byte
+	for _, s := range stringPool {
+		offsets = le.AppendUint32(offsets, uint32(len(stringData)))
+		chars := utf16.Encode([]rune(s))
+		stringData = le.AppendUint16(stringData, uint16(len(chars)))
+		for _, c := range chars {
+			stringData = le.AppendUint16(stringData, c)
+		}
+		stringData = le.AppendUint16(stringData, 0)
+	}
+	for len(stringData)%4 != 0 {
+		stringData = append(stringData, 0)
+	}
+	var poolHeader []byte
+	poolHeader = le.AppendUint32(poolHeader, uint32(len(stringPool)))
+	poolHeader = le.AppendUint32(poolHeader, 0)                       // style count
+	poolHeader = le.AppendUint32(poolHeader, 0)                       // flags
+	poolHeader = le.AppendUint32(poolHeader, uint32(28+len(offsets))) // strings start
+	poolHeader = le.AppendUint32(poolHeader, 0)                       // styles start
+	poolChunk := chunk(axmlChunkStringPool, 28, append(append(poolHeader, offsets...), stringData...))
+
+	var resourceIDs []byte
+	for _, id := range resourceMap {
+		resourceIDs = le.AppendUint32(resourceIDs, id)
+	}
+	resourceMapChunk := chunk(axmlChunkResourceMap, 8, resourceIDs)
+
+	startElement := func(name uint32, attrs []testAttribute) []byte {
+		var body []byte
+		body = le.AppendUint32(body, 1)           // line number
+		body = le.AppendUint32(body, axmlNoIndex) // comment
+		body = le.AppendUint32(body, axmlNoIndex) // namespace
+		body = le.AppendUint32(body, name)
+		body = le.AppendUint16(body, 20) // attribute start
+		body = le.AppendUint16(body, 20) // attribute size
+		body = le.AppendUint16(body, uint16(len(attrs)))
+		body = le.AppendUint16(body, 0) // id index
+		body = le.AppendUint16(body, 0) // class index
+		body = le.AppendUint16(body, 0) // style index
+		for _, attr := range attrs {
+			rawValue := uint32(axmlNoIndex)
+			if attr.dataType == axmlTypeString {
+				rawValue = attr.data
+			}
+			body = le.AppendUint32(body, axmlNoIndex)
+			body = le.AppendUint32(body, attr.name)
+			body = le.AppendUint32(body, rawValue)
+			body = le.AppendUint16(body, 8)
+			body = append(body, 0, attr.dataType)
+			body = le.AppendUint32(body, attr.data)
+		}
+		return chunk(axmlChunkStartElement, 16, body)
+	}
+	endElement := func(name uint32) []byte {
+		var body []byte
+		body = le.AppendUint32(body, 1)
+		body = le.AppendUint32(body, axmlNoIndex)
+		body = le.AppendUint32(body, axmlNoIndex)
+		body = le.AppendUint32(body, name)
+		return chunk(axmlChunkEndElement, 16, body)
+	}
+
+	var content []byte
+	content = append(content, poolChunk...)
+	content = append(content, resourceMapChunk...)
+	content = append(content, startElement(5, []testAttribute{
+		{name: 0, dataType: axmlTypeIntDec, data: versionCode},
+		{name: 1, dataType: axmlTypeString, data: 8},
+		{name: 4, dataType: axmlTypeString, data: 7},
+	})...)
+	content = append(content, startElement(6, []testAttribute{
+		{name: 2, dataType: axmlTypeIntDec, data: 21},
+		{name: 3, dataType: axmlTypeIntDec, data: 34},
+	})...)
+	content = append(content, endElement(6)...)
+	content = append(content, endElement(5)...)
+
+	return chunk(axmlChunkXML, 8, content)
+}
+
+func protoBytes(number int, value []byte) []byte {
+	out := binary.AppendUvarint(nil, uint64(number<<3|protoWireBytes))
+	out = binary.AppendUvarint(out, uint64(len(value)))
+	return append(out, value...)
+}
+
+func protoVarint(number int, value uint64) []byte {
+	out := binary.AppendUvarint(nil, uint64(number<<3|protoWireVarint))
+	return binary.AppendUvarint(out, value)
+}
+
+func protoAttribute(name, value string, resourceID uint32, intValue int) []byte {
+	var attr []byte
+	attr = append(attr, protoBytes(protoXMLAttributeName, []byte(name))...)
+	if value != "" {
+		attr = append(attr, protoBytes(protoXMLAttributeValue, []byte(value))...)
+	}
+	if resourceID != 0 {
+		attr = append(attr, protoVarint(protoXMLAttributeResID, uint64(resourceID))...)
+	}
+	if intValue != 0 {
+		prim := protoVarint(protoPrimitiveIntDecimal, uint64(intValue))
+		attr = append(attr, protoBytes(protoXMLAttributeCompiled, protoBytes(protoItemPrim, prim))...)
+	}
+	return protoBytes(protoXMLElementAttribute, attr)
+}
+
+// protoManifest encodes a minimal aapt2 protobuf manifest.
+func protoManifest() []byte {
+	var usesSDK []byte
+	usesSDK = append(usesSDK, protoBytes(protoXMLElementName, []byte("uses-sdk"))...)
+	usesSDK = append(usesSDK, protoAttribute("minSdkVersion", "", 0x0101020c, 21)...)
+	usesSDK = append(usesSDK, protoAttribute("targetSdkVersion", "34", 0x01010270, 34)...)
+
+	var manifest []byte
+	manifest = append(manifest, protoBytes(protoXMLElementName, []byte("manifest"))...)
+	manifest = append(manifest, protoAttribute("versionCode", "", 0x0101021b, 42)...)
+	manifest = append(manifest, protoAttribute("versionName", "1.2.3", 0x0101021c, 0)...)
+	manifest = append(manifest, protoAttribute("package", "io.bitrise.sample", 0, 0)...)
+	manifest = append(manifest, protoBytes(protoXMLElementChild, protoBytes(protoXMLNodeElement, usesSDK))...)
+
+	return protoBytes(protoXMLNodeElement, manifest)
+}
+
+func createTestZip(t *testing.T, pth string, files map[string][]byte) {
+	f, err := os.Create(pth)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+}
+
+func Test_inspectAndroidArtifact(t *testing.T) {
+	dir := t.TempDir()
+
+	apkPth := filepath.Join(dir, "app-release.apk")
+	createTestZip(t, apkPth, map[string][]byte{
+		"AndroidManifest.xml":                 binaryXMLManifest(),
+		"lib/arm64-v8a/libflutter.so":         {},
+		"lib/armeabi-v7a/libflutter.so":       {},
+		"lib/arm64-v8a/libapp.so":             {},
+		"assets/flutter_assets/AssetManifest": {},
+	})
+
+	aabPth := filepath.Join(dir, "app-release.aab")
+	createTestZip(t, aabPth, map[string][]byte{
+		"base/manifest/AndroidManifest.xml": protoManifest(),
+		"base/lib/x86_64/libflutter.so":     {},
+		"base/lib/arm64-v8a/libflutter.so":  {},
+		"BundleConfig.pb":                   {},
+	})
+
+	want := androidArtifactInfo{
+		applicationID:    "io.bitrise.sample",
+		versionCode:      "42",
+		versionName:      "1.2.3",
+		minSDKVersion:    "21",
+		targetSDKVersion: "34",
+	}
+
+	got, err := inspectAndroidArtifact(apkPth)
+	require.NoError(t, err)
+	wantAPK := want
+	wantAPK.abis = []string{"arm64-v8a", "armeabi-v7a"}
+	require.Equal(t, wantAPK, got)
+
+	got, err = inspectAndroidArtifact(aabPth)
+	require.NoError(t, err)
+	wantAAB := want
+	wantAAB.abis = []string{"arm64-v8a", "x86_64"}
+	require.Equal(t, wantAAB, got)
+}
+
+func Test_androidArtifactInfo_verify(t *testing.T) {
+	info := androidArtifactInfo{applicationID: "io.bitrise.sample", versionCode: "42", versionName: "1.2.3"}
+
+	require.NoError(t, info.verify(androidArtifactExpectations{}))
+	require.NoError(t, info.verify(androidArtifactExpectations{applicationID: "io.bitrise.sample", versionName: "1.2.3", versionCode: "42"}))
+	require.EqualError(t, info.verify(androidArtifactExpectations{applicationID: "io.bitrise.other", versionCode: "43"}),
+		"application ID is io.bitrise.sample, expected io.bitrise.other, version code is 42, expected 43")
+}
+
+func Test_androidArtifactExpectations_forSplitAPK(t *testing.T) {
+	expected := androidArtifactExpectations{applicationID: "io.bitrise.sample", versionName: "1.2.3", versionCode: "42"}
+
+	require.Equal(t, "1042", expected.forSplitAPK("armeabi-v7a").versionCode)
+	require.Equal(t, "2042", expected.forSplitAPK("arm64-v8a").versionCode)
+	require.Equal(t, "4042", expected.forSplitAPK("x86_64").versionCode)
+	require.Equal(t, expected, expected.forSplitAPK(""))
+	require.Equal(t, androidArtifactExpectations{}, androidArtifactExpectations{}.forSplitAPK("arm64-v8a"))
+}
+
+func TestBuildSpecification_inspectAndroidArtifacts_splitAPKs(t *testing.T) {
+	dir := t.TempDir()
+	armAPK := filepath.Join(dir, "app-armeabi-v7a-release.apk")
+	createTestZip(t, armAPK, map[string][]byte{"AndroidManifest.xml": binaryXMLManifestWithVersionCode(1042)})
+	arm64APK := filepath.Join(dir, "app-arm64-v8a-release.apk")
+	createTestZip(t, arm64APK, map[string][]byte{"AndroidManifest.xml": binaryXMLManifestWithVersionCode(2042)})
+
+	exporter := &fakeExporter{envs: map[string]string{}}
+	spec := BuildSpecification{
+		displayName:         "Android app",
+		exporter:            exporter,
+		primaryABI:          "arm64-v8a",
+		androidExpectations: androidArtifactExpectations{versionName: "1.2.3", versionCode: "42"},
+	}
+	require.NoError(t, spec.inspectAndroidArtifacts([]string{armAPK, arm64APK}))
+	require.Equal(t, "2042", exporter.envs["FLUTTER_ANDROID_VERSION_CODE"])
+
+	spec.androidExpectations.versionCode = "43"
+	require.EqualError(t, spec.inspectAndroidArtifacts([]string{armAPK, arm64APK}), "app-armeabi-v7a-release.apk does not match the expected values: version code is 1042, expected 1043")
+}
+
+func Test_splitAPKABI(t *testing.T) {
+	tests := []struct {
+		pth  string
+		want string
+	}{
+		{pth: "build/app/outputs/flutter-apk/app-arm64-v8a-release.apk", want: "arm64-v8a"},
+		{pth: "build/app/outputs/flutter-apk/app-armeabi-v7a-prod-release.apk", want: "armeabi-v7a"},
+		{pth: "build/app/outputs/flutter-apk/app-x86_64-release.apk", want: "x86_64"},
+		{pth: "build/app/outputs/flutter-apk/app-x86-release.apk", want: "x86"},
+		{pth: "build/app/outputs/flutter-apk/app-release.apk", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pth, func(t *testing.T) {
+			require.Equal(t, tt.want, splitAPKABI(tt.pth))
+		})
+	}
+
+	require.Equal(t, "BITRISE_APK_PATH_ARM64_V8A", splitAPKOutputEnvName("arm64-v8a"))
+}
+
+func Test_orderPrimaryABILast(t *testing.T) {
+	artifacts := []string{"app-arm64-v8a-release.apk", "app-armeabi-v7a-release.apk", "app-x86_64-release.apk"}
+
+	got, err := orderPrimaryABILast(artifacts, "arm64-v8a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"app-armeabi-v7a-release.apk", "app-x86_64-release.apk", "app-arm64-v8a-release.apk"}, got)
+
+	_, err = orderPrimaryABILast([]string{"app-release.apk"}, "arm64-v8a")
+	require.EqualError(t, err, "no split APK found for the primary ABI (arm64-v8a)")
+}
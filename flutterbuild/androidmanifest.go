@@ -0,0 +1,449 @@
+package flutterbuild
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+// manifestElement is an element of a compiled AndroidManifest.xml with its attribute values converted to strings.
+// Attributes are keyed by their name (without namespace prefix).
+type manifestElement struct {
+	name  string
+	depth int
+	attrs map[string]string
+}
+
+// Resource IDs of the android: attributes read from the manifest,
+// used when the attribute names are stripped from the string pool.
+var androidAttributeNames = map[uint32]string{
+	0x0101021b: "versionCode",
+	0x0101021c: "versionName",
+	0x0101020c: "minSdkVersion",
+	0x01010270: "targetSdkVersion",
+}
+
+// Binary XML (AXML) format, used for the AndroidManifest.xml in APKs:
+// https://android.googlesource.com/platform/frameworks/base/+/master/libs/androidfw/include/androidfw/ResourceTypes.h
+const (
+	axmlChunkStringPool   = 0x0001
+	axmlChunkXML          = 0x0003
+	axmlChunkResourceMap  = 0x0180
+	axmlChunkStartElement = 0x0102
+	axmlChunkEndElement   = 0x0103
+
+	axmlStringPoolUTF8Flag = 1 << 8
+	axmlNoIndex            = 0xffffffff
+
+	axmlTypeReference  = 0x01
+	axmlTypeString     = 0x03
+	axmlTypeFloat      = 0x04
+	axmlTypeIntDec     = 0x10
+	axmlTypeIntHex     = 0x11
+	axmlTypeIntBoolean = 0x12
+)
+
+// parseBinaryXMLManifest parses an AXML encoded AndroidManifest.xml.
+func parseBinaryXMLManifest(data []byte) ([]manifestElement, error) {
+	if len(data) < 8 || binary.LittleEndian.Uint16(data) != axmlChunkXML {
+		return nil, errors.New("not a binary XML file")
+	}
+
+	var stringPool []string
+	var resourceMap []uint32
+	var elements []manifestElement
+	depth := 0
+
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+8 <= len(data) {
+		chunkType := binary.LittleEndian.Uint16(data[offset:])
+		headerSize := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if chunkSize < 8 || offset+chunkSize > len(data) {
+			return nil, fmt.Errorf("invalid chunk size (%d) at offset %d", chunkSize, offset)
+		}
+		chunk := data[offset : offset+chunkSize]
+
+		switch chunkType {
+		case axmlChunkStringPool:
+			pool, err := parseAXMLStringPool(chunk)
+			if err != nil {
+				return nil, err
+			}
+			stringPool = pool
+		case axmlChunkResourceMap:
+			for i := headerSize; i+4 <= len(chunk); i += 4 {
+				resourceMap = append(resourceMap, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case axmlChunkStartElement:
+			element, err := parseAXMLStartElement(chunk, headerSize, stringPool, resourceMap)
+			if err != nil {
+				return nil, err
+			}
+			element.depth = depth
+			elements = append(elements, element)
+			depth++
+		case axmlChunkEndElement:
+			depth--
+		}
+
+		offset += chunkSize
+	}
+
+	return elements, nil
+}
+
+func parseAXMLStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errors.New("invalid string pool header")
+	}
+
+	stringCount := int(binary.LittleEndian.Uint32(chunk[8:]))
+	flags := binary.LittleEndian.Uint32(chunk[16:])
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	if headerSize+stringCount*4 > len(chunk) {
+		return nil, errors.New("invalid string pool size")
+	}
+
+	pool := make([]string, stringCount)
+	for i := 0; i < stringCount; i++ {
+		stringOffset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		if stringOffset >= len(chunk) {
+			return nil, fmt.Errorf("invalid string offset (%d)", stringOffset)
+		}
+
+		var err error
+		if flags&axmlStringPoolUTF8Flag != 0 {
+			pool[i], err = decodeAXMLUTF8String(chunk[stringOffset:])
+		} else {
+			pool[i], err = decodeAXMLUTF16String(chunk[stringOffset:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pool, nil
+}
+
+func decodeAXMLUTF8String(data []byte) (string, error) {
+	// The UTF-16 length of the string is followed by the UTF-8 length
+	_, n, err := decodeAXMLUTF8Length(data)
+	if err != nil {
+		return "", err
+	}
+	length, m, err := decodeAXMLUTF8Length(data[n:])
+	if err != nil {
+		return "", err
+	}
+
+	offset := n + m
+	if offset+length > len(data) {
+		return "", errors.New("invalid UTF-8 string length")
+	}
+	return string(data[offset : offset+length]), nil
+}
+
+// decodeAXMLUTF8Length decodes a length encoded on 1 or 2 bytes, returns the length and the number of bytes read.
+func decodeAXMLUTF8Length(data []byte) (int, int, error) {
+	if len(data) < 1 {
+		return 0, 0, errors.New("invalid UTF-8 string")
+	}
+	length := int(data[0])
+	if length&0x80 == 0 {
+		return length, 1, nil
+	}
+	if len(data) < 2 {
+		return 0, 0, errors.New("invalid UTF-8 string")
+	}
+	return (length&0x7f)<<8 | int(data[1]), 2, nil
+}
+
+func decodeAXMLUTF16String(data []byte) (string, error) {
+	if len(data) < 2 {
+		return "", errors.New("invalid UTF-16 string")
+	}
+	length := int(binary.LittleEndian.Uint16(data))
+	offset := 2
+	if length&0x8000 != 0 {
+		if len(data) < 4 {
+			return "", errors.New("invalid UTF-16 string")
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(data[2:]))
+		offset = 4
+	}
+	if offset+length*2 > len(data) {
+		return "", errors.New("invalid UTF-16 string length")
+	}
+
+	chars := make([]uint16, length)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(data[offset+i*2:])
+	}
+	return string(utf16.Decode(chars)), nil
+}
+
+func parseAXMLStartElement(chunk []byte, headerSize int, stringPool []string, resourceMap []uint32) (manifestElement, error) {
+	if headerSize+20 > len(chunk) {
+		return manifestElement{}, errors.New("invalid start element chunk")
+	}
+
+	ext := chunk[headerSize:]
+	element := manifestElement{
+		name:  axmlString(stringPool, binary.LittleEndian.Uint32(ext[4:])),
+		attrs: map[string]string{},
+	}
+
+	attributeStart := int(binary.LittleEndian.Uint16(ext[8:]))
+	attributeSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	attributeCount := int(binary.LittleEndian.Uint16(ext[12:]))
+	for i := 0; i < attributeCount; i++ {
+		attrOffset := attributeStart + i*attributeSize
+		if attrOffset+20 > len(ext) {
+			return manifestElement{}, errors.New("invalid attribute")
+		}
+		attr := ext[attrOffset:]
+
+		nameIndex := binary.LittleEndian.Uint32(attr[4:])
+		name := axmlString(stringPool, nameIndex)
+		if int(nameIndex) < len(resourceMap) {
+			if resourceName, ok := androidAttributeNames[resourceMap[nameIndex]]; ok {
+				name = resourceName
+			}
+		}
+
+		rawValue := binary.LittleEndian.Uint32(attr[8:])
+		dataType := attr[15]
+		data := binary.LittleEndian.Uint32(attr[16:])
+
+		var value string
+		switch dataType {
+		case axmlTypeString:
+			value = axmlString(stringPool, data)
+		case axmlTypeIntDec:
+			value = strconv.Itoa(int(int32(data)))
+		case axmlTypeIntHex:
+			value = fmt.Sprintf("0x%x", data)
+		case axmlTypeIntBoolean:
+			value = strconv.FormatBool(data != 0)
+		case axmlTypeFloat:
+			value = strconv.FormatFloat(float64(math.Float32frombits(data)), 'f', -1, 32)
+		case axmlTypeReference:
+			value = fmt.Sprintf("@0x%08x", data)
+		default:
+			value = axmlString(stringPool, rawValue)
+		}
+		element.attrs[name] = value
+	}
+
+	return element, nil
+}
+
+func axmlString(stringPool []string, index uint32) string {
+	if index == axmlNoIndex || int(index) >= len(stringPool) {
+		return ""
+	}
+	return stringPool[index]
+}
+
+// Protocol buffer format, used for the AndroidManifest.xml in app bundles (aapt2 Resources.proto XmlNode):
+// https://android.googlesource.com/platform/frameworks/base/+/master/tools/aapt2/Resources.proto
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+
+	protoXMLNodeElement        = 1
+	protoXMLElementName        = 3
+	protoXMLElementAttribute   = 4
+	protoXMLElementChild       = 5
+	protoXMLAttributeName      = 2
+	protoXMLAttributeValue     = 3
+	protoXMLAttributeResID     = 5
+	protoXMLAttributeCompiled  = 6
+	protoItemStr               = 2
+	protoItemPrim              = 7
+	protoStringValue           = 1
+	protoPrimitiveIntDecimal   = 6
+	protoPrimitiveIntHex       = 7
+	protoPrimitiveBooleanValue = 8
+)
+
+type protoField struct {
+	number int
+	varint uint64
+	bytes  []byte
+}
+
+func parseProtoFields(data []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("invalid protobuf field key")
+		}
+		data = data[n:]
+
+		field := protoField{number: int(key >> 3)}
+		switch key & 0x7 {
+		case protoWireVarint:
+			value, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, errors.New("invalid protobuf varint")
+			}
+			field.varint = value
+			data = data[n:]
+		case protoWireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, errors.New("invalid protobuf length")
+			}
+			field.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case protoWireFixed64:
+			if len(data) < 8 {
+				return nil, errors.New("invalid protobuf fixed64")
+			}
+			data = data[8:]
+		case protoWireFixed32:
+			if len(data) < 4 {
+				return nil, errors.New("invalid protobuf fixed32")
+			}
+			field.varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type: %d", key&0x7)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseProtoManifest parses a protobuf encoded AndroidManifest.xml.
+func parseProtoManifest(data []byte) ([]manifestElement, error) {
+	var elements []manifestElement
+	if err := parseProtoXMLNode(data, 0, &elements); err != nil {
+		return nil, err
+	}
+	if len(elements) == 0 {
+		return nil, errors.New("no XML element found")
+	}
+	return elements, nil
+}
+
+func parseProtoXMLNode(data []byte, depth int, elements *[]manifestElement) error {
+	fields, err := parseProtoFields(data)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if field.number != protoXMLNodeElement {
+			continue
+		}
+
+		elementFields, err := parseProtoFields(field.bytes)
+		if err != nil {
+			return err
+		}
+
+		element := manifestElement{depth: depth, attrs: map[string]string{}}
+		var children [][]byte
+		for _, elementField := range elementFields {
+			switch elementField.number {
+			case protoXMLElementName:
+				element.name = string(elementField.bytes)
+			case protoXMLElementAttribute:
+				name, value, err := parseProtoXMLAttribute(elementField.bytes)
+				if err != nil {
+					return err
+				}
+				element.attrs[name] = value
+			case protoXMLElementChild:
+				children = append(children, elementField.bytes)
+			}
+		}
+
+		*elements = append(*elements, element)
+		for _, child := range children {
+			if err := parseProtoXMLNode(child, depth+1, elements); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseProtoXMLAttribute(data []byte) (string, string, error) {
+	fields, err := parseProtoFields(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	var name, value string
+	var compiledValue string
+	for _, field := range fields {
+		switch field.number {
+		case protoXMLAttributeName:
+			name = string(field.bytes)
+		case protoXMLAttributeValue:
+			value = string(field.bytes)
+		case protoXMLAttributeResID:
+			if resourceName, ok := androidAttributeNames[uint32(field.varint)]; ok {
+				name = resourceName
+			}
+		case protoXMLAttributeCompiled:
+			compiledValue, err = parseProtoItem(field.bytes)
+			if err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	if value == "" {
+		value = compiledValue
+	}
+	return name, value, nil
+}
+
+func parseProtoItem(data []byte) (string, error) {
+	fields, err := parseProtoFields(data)
+	if err != nil {
+		return "", err
+	}
+
+	for _, field := range fields {
+		switch field.number {
+		case protoItemStr:
+			strFields, err := parseProtoFields(field.bytes)
+			if err != nil {
+				return "", err
+			}
+			for _, strField := range strFields {
+				if strField.number == protoStringValue {
+					return string(strField.bytes), nil
+				}
+			}
+		case protoItemPrim:
+			primFields, err := parseProtoFields(field.bytes)
+			if err != nil {
+				return "", err
+			}
+			for _, primField := range primFields {
+				switch primField.number {
+				case protoPrimitiveIntDecimal:
+					return strconv.Itoa(int(int32(primField.varint))), nil
+				case protoPrimitiveIntHex:
+					return fmt.Sprintf("0x%x", uint32(primField.varint)), nil
+				case protoPrimitiveBooleanValue:
+					return strconv.FormatBool(primField.varint != 0), nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
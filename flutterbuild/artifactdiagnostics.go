@@ -0,0 +1,167 @@
+package flutterbuild
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+)
+
+// maxListedCandidates limits the artifact candidates listed in the diagnostics.
+const maxListedCandidates = 10
+
+// artifactExtensions are the extensions of the output types' artifacts, the first one is exported by the Step.
+var artifactExtensions = map[OutputType][]string{
+	OutputTypeAPK:       {".apk"},
+	OutputTypeAppBundle: {".aab"},
+	OutputTypeIOSApp:    {".app"},
+	OutputTypeArchive:   {".xcarchive", ".ipa"},
+}
+
+// artifactCandidate is a path under the build directory, which looks like an artifact of the output type.
+type artifactCandidate struct {
+	path    string
+	modTime time.Time
+}
+
+// findArtifactCandidates returns the paths under the project's build directory with the output type's artifact extensions,
+// the most recently modified first. Bundles (.app, .xcarchive) are not walked.
+func findArtifactCandidates(projectLocation string, outputType OutputType) ([]artifactCandidate, error) {
+	buildDir := filepath.Join(projectLocation, "build")
+	if _, err := os.Stat(buildDir); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	extensions := artifactExtensions[outputType]
+	var candidates []artifactCandidate
+	err := filepath.WalkDir(buildDir, func(pth string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		ext := filepath.Ext(pth)
+		isBundle := entry.IsDir() && (ext == ".app" || ext == ".xcarchive")
+		if !sliceutil.IsStringInSlice(ext, extensions) {
+			if isBundle {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, artifactCandidate{path: pth, modTime: artifactModTime(pth, info)})
+
+		if isBundle {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].modTime.After(candidates[j].modTime)
+	})
+	return candidates, nil
+}
+
+// suggestOutputPattern returns an output pattern matching the artifacts next to the candidate.
+func suggestOutputPattern(projectLocation, candidate string) string {
+	rel, err := filepath.Rel(projectLocation, filepath.Dir(candidate))
+	if err != nil {
+		return ""
+	}
+	return "*" + filepath.ToSlash(rel) + "/*" + filepath.Ext(candidate)
+}
+
+// missingArtifactsError returns the error of the output patterns not matching any artifact of the output type,
+// with the artifacts dropped for having an other type and the artifact candidates under the build directory.
+func (spec BuildSpecification) missingArtifactsError(matches []patternMatch) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, `artifact path pattern (%s) did not match any artifacts on the path (%s).
+Check that 'iOS/Android Output Pattern' and 'Project Location' is correct`, spec.outputPathPatterns, spec.projectLocation)
+
+	if isAndroidOutputType(spec.platformOutputType) {
+		var dropped []string
+		for _, match := range matches {
+			for _, pth := range match.paths {
+				dropped = append(dropped, fmt.Sprintf("- %s: %s", match.pattern, pth))
+			}
+		}
+		if len(dropped) > 0 {
+			fmt.Fprintf(&msg, "\nArtifacts matched by the patterns, but dropped as not the selected output type (%s):\n%s", spec.platformOutputType, strings.Join(dropped, "\n"))
+		}
+	}
+
+	candidates, err := findArtifactCandidates(spec.projectLocation, spec.platformOutputType)
+	if err != nil {
+		fmt.Fprintf(&msg, "\nFailed to look for %s artifacts in the build directory: %s", spec.platformOutputType, err)
+		return errors.New(msg.String())
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintf(&msg, "\nNo %s artifacts (%s) found in the build directory (%s)", spec.platformOutputType, strings.Join(artifactExtensions[spec.platformOutputType], ", "), filepath.Join(spec.projectLocation, "build"))
+		return errors.New(msg.String())
+	}
+
+	fmt.Fprintf(&msg, "\n%s artifacts found in the build directory:", spec.platformOutputType)
+	for i, candidate := range candidates {
+		if i == maxListedCandidates {
+			fmt.Fprintf(&msg, "\n- ... and %d more", len(candidates)-maxListedCandidates)
+			break
+		}
+		fmt.Fprintf(&msg, "\n- %s", candidate.path)
+		if spec.matchesOutputPatterns(candidate.path) {
+			fmt.Fprintf(&msg, " (matches the output patterns, but %s)", spec.candidateSkipReason(candidate.path))
+		}
+	}
+
+	primaryExtension := artifactExtensions[spec.platformOutputType][0]
+	for _, candidate := range candidates {
+		if filepath.Ext(candidate.path) == primaryExtension && !spec.matchesOutputPatterns(candidate.path) {
+			fmt.Fprintf(&msg, "\nSuggested output pattern: %s", suggestOutputPattern(spec.projectLocation, candidate.path))
+			break
+		}
+	}
+	return errors.New(msg.String())
+}
+
+// candidateSkipReason returns why a candidate matching the output patterns is not an artifact of the output type.
+func (spec BuildSpecification) candidateSkipReason(pth string) string {
+	primaryExtension := artifactExtensions[spec.platformOutputType][0]
+	if filepath.Ext(pth) != primaryExtension {
+		return fmt.Sprintf("%s artifacts are %s", spec.platformOutputType, primaryExtension)
+	}
+
+	info, err := os.Stat(pth)
+	if err != nil {
+		return fmt.Sprintf("it is not accessible: %s", err)
+	}
+	if isDir := !isAndroidOutputType(spec.platformOutputType); info.IsDir() != isDir {
+		if isDir {
+			return fmt.Sprintf("it is a file, %s artifacts are directories", spec.platformOutputType)
+		}
+		return fmt.Sprintf("it is a directory, %s artifacts are files", spec.platformOutputType)
+	}
+	return "it was not found when searching the project"
+}
+
+// matchesOutputPatterns reports whether any of the output patterns matches pth.
+func (spec BuildSpecification) matchesOutputPatterns(pth string) bool {
+	for _, outputPathPattern := range spec.outputPathPatterns {
+		pattern, err := newOutputPattern(spec.projectLocation, outputPathPattern)
+		if err == nil && pattern.match(pth) {
+			return true
+		}
+	}
+	return false
+}
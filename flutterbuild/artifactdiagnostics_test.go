@@ -0,0 +1,112 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_suggestOutputPattern(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate string
+		want      string
+	}{
+		{
+			name:      "APK",
+			candidate: "/project/build/app/outputs/flutter-apk/app-release.apk",
+			want:      "*build/app/outputs/flutter-apk/*.apk",
+		},
+		{
+			name:      "Archive",
+			candidate: "/project/build/ios/archive/Runner.xcarchive",
+			want:      "*build/ios/archive/*.xcarchive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestOutputPattern("/project", tt.candidate)
+			require.Equal(t, tt.want, got)
+
+			pattern, err := newOutputPattern("/project", got)
+			require.NoError(t, err)
+			require.True(t, pattern.match(tt.candidate))
+		})
+	}
+}
+
+func Test_findArtifactCandidates(t *testing.T) {
+	projectDir := t.TempDir()
+	archive := filepath.Join(projectDir, "build", "ios", "archive", "Runner.xcarchive")
+	require.NoError(t, os.MkdirAll(filepath.Join(archive, "Products", "Applications", "Runner.app"), 0755))
+	ipa := filepath.Join(projectDir, "build", "ios", "ipa", "Runner.ipa")
+	require.NoError(t, os.MkdirAll(filepath.Dir(ipa), 0755))
+	require.NoError(t, os.WriteFile(ipa, nil, 0644))
+	require.NoError(t, os.Chtimes(ipa, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	candidates, err := findArtifactCandidates(projectDir, OutputTypeArchive)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	require.Equal(t, archive, candidates[0].path)
+	require.Equal(t, ipa, candidates[1].path)
+
+	candidates, err = findArtifactCandidates(projectDir, OutputTypeIOSApp)
+	require.NoError(t, err)
+	require.Empty(t, candidates)
+
+	candidates, err = findArtifactCandidates(t.TempDir(), OutputTypeAPK)
+	require.NoError(t, err)
+	require.Empty(t, candidates)
+}
+
+func TestBuildSpecification_missingArtifactsError(t *testing.T) {
+	projectDir := t.TempDir()
+	aab := filepath.Join(projectDir, "build", "app", "outputs", "bundle", "release", "app-release.aab")
+	apk := filepath.Join(projectDir, "build", "app", "outputs", "flutter-apk", "app-release.apk")
+	for _, pth := range []string{aab, apk} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(t, os.WriteFile(pth, nil, 0644))
+	}
+
+	spec := BuildSpecification{
+		platformOutputType: OutputTypeAPK,
+		outputPathPatterns: []string{"*build/app/outputs/apk/*/*.apk", "*build/app/outputs/bundle/*/*.aab"},
+		projectLocation:    projectDir,
+	}
+	matches, err := spec.artifactMatches()
+	require.NoError(t, err)
+	require.Empty(t, matchedArtifacts(spec.platformOutputType, matches))
+
+	require.EqualError(t, spec.missingArtifactsError(matches), `artifact path pattern ([*build/app/outputs/apk/*/*.apk *build/app/outputs/bundle/*/*.aab]) did not match any artifacts on the path (`+projectDir+`).
+Check that 'iOS/Android Output Pattern' and 'Project Location' is correct
+Artifacts matched by the patterns, but dropped as not the selected output type (apk):
+- *build/app/outputs/bundle/*/*.aab: `+aab+`
+apk artifacts found in the build directory:
+- `+apk+`
+Suggested output pattern: *build/app/outputs/flutter-apk/*.apk`)
+
+	spec.projectLocation = t.TempDir()
+	require.EqualError(t, spec.missingArtifactsError(nil), `artifact path pattern ([*build/app/outputs/apk/*/*.apk *build/app/outputs/bundle/*/*.aab]) did not match any artifacts on the path (`+spec.projectLocation+`).
+Check that 'iOS/Android Output Pattern' and 'Project Location' is correct
+No apk artifacts (.apk) found in the build directory (`+filepath.Join(spec.projectLocation, "build")+`)`)
+}
+
+func TestBuildSpecification_missingArtifactsError_skipReason(t *testing.T) {
+	projectDir := t.TempDir()
+	ipa := filepath.Join(projectDir, "build", "ios", "ipa", "Runner.ipa")
+	require.NoError(t, os.MkdirAll(filepath.Dir(ipa), 0755))
+	require.NoError(t, os.WriteFile(ipa, nil, 0644))
+
+	spec := BuildSpecification{
+		platformOutputType: OutputTypeArchive,
+		outputPathPatterns: []string{"*build/ios/*/*"},
+		projectLocation:    projectDir,
+	}
+	require.EqualError(t, spec.missingArtifactsError(nil), `artifact path pattern ([*build/ios/*/*]) did not match any artifacts on the path (`+projectDir+`).
+Check that 'iOS/Android Output Pattern' and 'Project Location' is correct
+archive artifacts found in the build directory:
+- `+ipa+` (matches the output patterns, but archive artifacts are .xcarchive)`)
+}
@@ -0,0 +1,147 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+const gitShortSHAVariable = "git_short_sha"
+
+var (
+	artifactNameVariableRegexp = regexp.MustCompile(`\{([a-z_]*)\}`)
+	// repeatedSeparatorRegexp matches the separators left next to each other by empty variables
+	repeatedSeparatorRegexp = regexp.MustCompile(`([-_.])[-_.]+`)
+)
+
+// artifactNameVariables are the values of the artifact naming template variables.
+type artifactNameVariables struct {
+	appName     string
+	versionName string
+	buildNumber string
+	flavor      string
+	buildMode   string
+	abi         string
+	gitShortSHA string
+	platform    string
+}
+
+func (vars artifactNameVariables) values() map[string]string {
+	return map[string]string{
+		"app_name":          vars.appName,
+		"version_name":      vars.versionName,
+		"build_number":      vars.buildNumber,
+		"flavor":            vars.flavor,
+		"build_mode":        vars.buildMode,
+		"abi":               vars.abi,
+		gitShortSHAVariable: vars.gitShortSHA,
+		"platform":          vars.platform,
+	}
+}
+
+// validateArtifactNameTemplate checks that the template uses only known variables.
+func validateArtifactNameTemplate(template string) error {
+	known := artifactNameVariables{}.values()
+	for _, match := range artifactNameVariableRegexp.FindAllStringSubmatch(template, -1) {
+		if _, ok := known[match[1]]; !ok {
+			return fmt.Errorf("unknown variable %s in artifact name template (%s), use app_name, version_name, build_number, flavor, build_mode, abi, git_short_sha or platform", match[0], template)
+		}
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("artifact name template (%s) must not contain path separators", template)
+	}
+	return nil
+}
+
+// renderArtifactName substitutes the variables of the template. Separators left next to each other by empty variables
+// are merged and leading or trailing separators are removed.
+func renderArtifactName(template string, vars artifactNameVariables) string {
+	values := vars.values()
+	name := artifactNameVariableRegexp.ReplaceAllStringFunc(template, func(variable string) string {
+		value := values[strings.Trim(variable, "{}")]
+		return strings.NewReplacer("/", "-", `\`, "-").Replace(value)
+	})
+	name = repeatedSeparatorRegexp.ReplaceAllString(name, "$1")
+	return strings.Trim(name, "-_.")
+}
+
+// flutterBuildFlavorAndMode returns the --flavor and the build mode (debug, profile or release) of the flutter build parameters.
+func flutterBuildFlavorAndMode(params string) (string, string, error) {
+	args, err := shellquote.Split(params)
+	if err != nil {
+		return "", "", err
+	}
+
+	flavor, mode := "", "release"
+	for i, arg := range args {
+		switch {
+		case arg == "--flavor" && i+1 < len(args):
+			flavor = args[i+1]
+		case strings.HasPrefix(arg, "--flavor="):
+			flavor = strings.TrimPrefix(arg, "--flavor=")
+		case arg == "--debug" || arg == "--profile" || arg == "--release":
+			mode = strings.TrimPrefix(arg, "--")
+		}
+	}
+	return flavor, mode, nil
+}
+
+// artifactNamer names the files deployed into the deploy dir and detects the files deployed to the same path.
+type artifactNamer struct {
+	template  string
+	deployDir string
+	// deployed maps the deployed paths to their source artifacts
+	deployed map[string]deployedArtifact
+}
+
+// deployedArtifact is a file written into the deploy dir: the source artifact and the kind of the file, its extension.
+// A source can be deployed as several kinds, for example the size analysis report and its summary.
+type deployedArtifact struct {
+	source string
+	kind   string
+}
+
+func newArtifactNamer(template, deployDir string) *artifactNamer {
+	return &artifactNamer{template: template, deployDir: deployDir, deployed: map[string]deployedArtifact{}}
+}
+
+// name returns the file name rendered from the template with the ext extension, or defaultName if no template is set.
+func (namer *artifactNamer) name(source, defaultName, ext string, vars artifactNameVariables) (string, error) {
+	if namer.template == "" {
+		return defaultName, nil
+	}
+	rendered := renderArtifactName(namer.template, vars)
+	if rendered == "" {
+		return "", fmt.Errorf("artifact name template (%s) renders an empty name for %s", namer.template, source)
+	}
+	return rendered + ext, nil
+}
+
+// deployPath returns the path of the source artifact in the deploy dir, named by name.
+// Deploying two artifacts to the same path is an error, overwriting a file of a previous Step run is logged.
+func (namer *artifactNamer) deployPath(source, defaultName, ext string, vars artifactNameVariables) (string, error) {
+	name, err := namer.name(source, defaultName, ext, vars)
+	if err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(namer.deployDir, name)
+	artifact := deployedArtifact{source: source, kind: ext}
+	if previous, ok := namer.deployed[pth]; ok {
+		if previous == artifact {
+			return pth, nil
+		}
+		return "", fmt.Errorf("%s and %s would both be deployed as %s, use an artifact name template with variables distinguishing them", previous.source, source, name)
+	}
+	if _, err := os.Stat(pth); err == nil {
+		log.Warnf("%s already exists in the deploy directory, overwriting it with %s", name, source)
+	}
+
+	namer.deployed[pth] = artifact
+	return pth, nil
+}
@@ -0,0 +1,159 @@
+package flutterbuild
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_renderArtifactName(t *testing.T) {
+	vars := artifactNameVariables{
+		appName:     "my_app",
+		versionName: "1.2.3",
+		buildNumber: "42",
+		buildMode:   "release",
+		platform:    "android",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		abi      string
+		want     string
+	}{
+		{
+			name:     "All variables set",
+			template: "{app_name}-{platform}-{version_name}+{build_number}-{abi}",
+			abi:      "arm64-v8a",
+			want:     "my_app-android-1.2.3+42-arm64-v8a",
+		},
+		{
+			name:     "Empty variables merge separators",
+			template: "{app_name}-{flavor}-{build_mode}_{abi}",
+			want:     "my_app-release",
+		},
+		{
+			name:     "Leading empty variable",
+			template: "{flavor}-{app_name}",
+			want:     "my_app",
+		},
+		{
+			name:     "Path separators in values are replaced",
+			template: "{app_name}-{abi}",
+			abi:      "x86/64",
+			want:     "my_app-x86-64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := vars
+			v.abi = tt.abi
+			require.Equal(t, tt.want, renderArtifactName(tt.template, v))
+		})
+	}
+}
+
+func Test_validateArtifactNameTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{
+			name:     "Empty",
+			template: "",
+		},
+		{
+			name:     "Known variables",
+			template: "{app_name}-{git_short_sha}",
+		},
+		{
+			name:     "Unknown variable",
+			template: "{app_name}-{version}",
+			wantErr:  "unknown variable {version} in artifact name template ({app_name}-{version}), use app_name, version_name, build_number, flavor, build_mode, abi, git_short_sha or platform",
+		},
+		{
+			name:     "Path separator",
+			template: "out/{app_name}",
+			wantErr:  "artifact name template (out/{app_name}) must not contain path separators",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArtifactNameTemplate(tt.template)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_flutterBuildFlavorAndMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     string
+		wantFlavor string
+		wantMode   string
+	}{
+		{
+			name:     "Defaults",
+			params:   " ",
+			wantMode: "release",
+		},
+		{
+			name:       "Separate flavor value",
+			params:     "--flavor staging --debug",
+			wantFlavor: "staging",
+			wantMode:   "debug",
+		},
+		{
+			name:       "Flavor with equal sign",
+			params:     "--profile --flavor=prod",
+			wantFlavor: "prod",
+			wantMode:   "profile",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flavor, mode, err := flutterBuildFlavorAndMode(tt.params)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFlavor, flavor)
+			require.Equal(t, tt.wantMode, mode)
+		})
+	}
+}
+
+func Test_artifactNamer_deployPath(t *testing.T) {
+	deployDir := t.TempDir()
+	vars := artifactNameVariables{appName: "my_app", versionName: "1.2.3"}
+
+	namer := newArtifactNamer("", deployDir)
+	pth, err := namer.deployPath("/build/app-release.apk", "app-release.apk", ".apk", vars)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(deployDir, "app-release.apk"), pth)
+
+	namer = newArtifactNamer("{app_name}-{version_name}", deployDir)
+	pth, err = namer.deployPath("/build/app-arm64-v8a-release.apk", "app-arm64-v8a-release.apk", ".apk", vars)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(deployDir, "my_app-1.2.3.apk"), pth)
+
+	pth, err = namer.deployPath("/build/app-arm64-v8a-release.apk", "app-arm64-v8a-release.apk", ".apk", vars)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(deployDir, "my_app-1.2.3.apk"), pth)
+
+	_, err = namer.deployPath("/build/app-x86_64-release.apk", "app-x86_64-release.apk", ".apk", vars)
+	require.EqualError(t, err, "/build/app-arm64-v8a-release.apk and /build/app-x86_64-release.apk would both be deployed as my_app-1.2.3.apk, use an artifact name template with variables distinguishing them")
+
+	namer = newArtifactNamer("", deployDir)
+	_, err = namer.deployPath("/build/report.json", "report", ".code-size-analysis.json", vars)
+	require.NoError(t, err)
+	_, err = namer.deployPath("/build/report.json", "report", ".size-analysis-summary.txt", vars)
+	require.EqualError(t, err, "/build/report.json and /build/report.json would both be deployed as report, use an artifact name template with variables distinguishing them")
+
+	namer = newArtifactNamer("{flavor}", deployDir)
+	_, err = namer.deployPath("/build/app-release.apk", "app-release.apk", ".apk", vars)
+	require.EqualError(t, err, "artifact name template ({flavor}) renders an empty name for /build/app-release.apk")
+}
@@ -0,0 +1,90 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// nonRetryableSignatures are build output fragments of failures which retrying does not fix,
+// they take precedence over the retryable signatures.
+var nonRetryableSignatures = []string{
+	"Target kernel_snapshot failed",
+	"Compilation failed",
+	"Compilation error",
+	"Code signing is required",
+	"No valid code signing certificates",
+	"No profiles for",
+}
+
+// retryPolicy configures retrying failed builds.
+type retryPolicy struct {
+	// maxAttempts is the maximum number of build attempts, values below 2 disable retrying
+	maxAttempts int
+	// signatures are build output fragments of transient failures
+	signatures []string
+}
+
+func (policy retryPolicy) attempts() int {
+	if policy.maxAttempts < 1 {
+		return 1
+	}
+	return policy.maxAttempts
+}
+
+// classify returns whether a build failure with the given output is worth retrying, and why.
+func (policy retryPolicy) classify(output string) (bool, string) {
+	lowerOutput := strings.ToLower(output)
+	for _, signature := range nonRetryableSignatures {
+		if strings.Contains(lowerOutput, strings.ToLower(signature)) {
+			return false, fmt.Sprintf("non-retryable failure: %s", signature)
+		}
+	}
+	for _, signature := range policy.signatures {
+		if signature != "" && strings.Contains(lowerOutput, strings.ToLower(signature)) {
+			return true, fmt.Sprintf("retryable failure: %s", signature)
+		}
+	}
+	return false, "no retryable failure signature found in the output"
+}
+
+// parseRetrySignatures returns the non-empty, trimmed lines of the retry signatures input.
+func parseRetrySignatures(lines []string) []string {
+	var signatures []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			signatures = append(signatures, line)
+		}
+	}
+	return signatures
+}
+
+// outputTailSize is the amount of build output kept for classifying failures.
+const outputTailSize = 1 << 20
+
+// outputTail keeps the last outputTailSize bytes written to it, it is safe for concurrent use.
+type outputTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (tail *outputTail) Write(p []byte) (int, error) {
+	tail.mu.Lock()
+	defer tail.mu.Unlock()
+
+	tail.buf = append(tail.buf, p...)
+	if len(tail.buf) > 2*outputTailSize {
+		tail.buf = append([]byte{}, tail.buf[len(tail.buf)-outputTailSize:]...)
+	}
+	return len(p), nil
+}
+
+func (tail *outputTail) String() string {
+	tail.mu.Lock()
+	defer tail.mu.Unlock()
+
+	if len(tail.buf) > outputTailSize {
+		return string(tail.buf[len(tail.buf)-outputTailSize:])
+	}
+	return string(tail.buf)
+}
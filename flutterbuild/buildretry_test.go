@@ -0,0 +1,159 @@
+package flutterbuild
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_retryPolicy_classify(t *testing.T) {
+	policy := retryPolicy{
+		maxAttempts: 3,
+		signatures:  parseRetrySignatures([]string{"Could not resolve all files for configuration", "  ", "CDN: trunk URL couldn't be downloaded"}),
+	}
+
+	tests := []struct {
+		name       string
+		output     string
+		want       bool
+		wantReason string
+	}{
+		{
+			name:       "gradle dependency download",
+			output:     "FAILURE: Build failed with an exception.\n* What went wrong:\nCould not resolve all files for configuration ':app:debugRuntimeClasspath'.",
+			want:       true,
+			wantReason: "retryable failure: Could not resolve all files for configuration",
+		},
+		{
+			name:       "cocoapods cdn, case insensitive",
+			output:     "[!] cdn: TRUNK URL couldn't be downloaded: https://cdn.cocoapods.org/",
+			want:       true,
+			wantReason: "retryable failure: CDN: trunk URL couldn't be downloaded",
+		},
+		{
+			name:       "compile error wins",
+			output:     "lib/main.dart:3:1: Error: Expected ';' after this.\nTarget kernel_snapshot failed: Exception\nCould not resolve all files for configuration",
+			want:       false,
+			wantReason: "non-retryable failure: Target kernel_snapshot failed",
+		},
+		{
+			name:       "unknown failure",
+			output:     "Gradle task assembleRelease failed with exit code 1",
+			want:       false,
+			wantReason: "no retryable failure signature found in the output",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := policy.classify(tt.output)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
+
+func Test_outputTail(t *testing.T) {
+	var tail outputTail
+	_, err := tail.Write([]byte(strings.Repeat("a", outputTailSize)))
+	require.NoError(t, err)
+	_, err = tail.Write([]byte(strings.Repeat("b", outputTailSize+1)))
+	require.NoError(t, err)
+	_, err = tail.Write([]byte("end"))
+	require.NoError(t, err)
+
+	got := tail.String()
+	require.Len(t, got, outputTailSize)
+	require.True(t, strings.HasSuffix(got, "bend"))
+	require.False(t, strings.Contains(got, "a"))
+}
+
+// scriptedBuildRunner is a CommandRunner running the build attempts in order, each writing its output and failing with its error.
+type scriptedBuildRunner struct {
+	attempts []scriptedAttempt
+	runs     int
+}
+
+type scriptedAttempt struct {
+	output string
+	err    error
+}
+
+func (runner *scriptedBuildRunner) Run(cmd Command) error {
+	attempt := runner.attempts[runner.runs]
+	runner.runs++
+	if _, err := io.WriteString(cmd.Stdout, attempt.output); err != nil {
+		return err
+	}
+	return attempt.err
+}
+
+func (runner *scriptedBuildRunner) Output(dir, name string, args ...string) (string, error) {
+	return "", fmt.Errorf("unexpected command: %s", name)
+}
+
+func (runner *scriptedBuildRunner) LookPath(name string) (string, error) {
+	return "", fmt.Errorf("executable file not found in $PATH: %s", name)
+}
+
+func TestBuildSpecification_Build_retry(t *testing.T) {
+	buildErr := errors.New("exit status 1")
+	tests := []struct {
+		name     string
+		attempts []scriptedAttempt
+		wantRuns int
+		wantErr  error
+	}{
+		{
+			name: "retryable failure then success",
+			attempts: []scriptedAttempt{
+				{output: "Could not resolve all files for configuration ':app:debugCompileClasspath'.\n", err: buildErr},
+				{output: "✓ Built build/app/outputs/flutter-apk/app-release.apk\n"},
+			},
+			wantRuns: 2,
+		},
+		{
+			name: "non-retryable failure",
+			attempts: []scriptedAttempt{
+				{output: "Could not resolve all files for configuration\nCompilation failed\n", err: buildErr},
+				{output: "✓ Built build/app/outputs/flutter-apk/app-release.apk\n"},
+			},
+			wantRuns: 1,
+			wantErr:  buildErr,
+		},
+		{
+			name: "retryable failure on every attempt",
+			attempts: []scriptedAttempt{
+				{output: "Could not resolve all files for configuration\n", err: buildErr},
+				{output: "Could not resolve all files for configuration\n", err: buildErr},
+				{output: "Could not resolve all files for configuration\n", err: buildErr},
+			},
+			wantRuns: 3,
+			wantErr:  buildErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &scriptedBuildRunner{attempts: tt.attempts}
+			var output bytes.Buffer
+			spec := BuildSpecification{
+				displayName:        "Android app",
+				platformOutputType: OutputTypeAPK,
+				runner:             runner,
+				stdout:             &output,
+				stderr:             &output,
+				retryPolicy:        retryPolicy{maxAttempts: 3, signatures: []string{"Could not resolve all files for configuration"}},
+			}
+
+			err := spec.Build()
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.wantRuns, runner.runs)
+			require.Contains(t, output.String(), `$ flutter "build" "apk"`, "the build log is written to the build output")
+			require.Contains(t, output.String(), "Build attempt 1/3")
+		})
+	}
+}
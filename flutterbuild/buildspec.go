@@ -0,0 +1,674 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/bitrise-io/go-utils/ziputil"
+	"github.com/kballard/go-shellquote"
+)
+
+// BuildSpecification is the build of a platform computed by FlutterBuilder.ProcessConfig:
+// Build runs flutter build and ExportOutputs exports the artifacts.
+type BuildSpecification struct {
+	displayName          string
+	platformOutputType   OutputType
+	platformSelectors    []string
+	outputPathPatterns   []string
+	additionalParameters string
+	projectLocation      string
+	deployDir            string
+	runner               CommandRunner
+	exporter             OutputExporter
+	buildStartTime       time.Time
+	iosBundleID          string
+	androidExpectations  androidArtifactExpectations
+	timeouts             Timeouts
+	retryPolicy          retryPolicy
+	// stdout and stderr receive the output of the build command, os.Stdout and os.Stderr are used if nil
+	stdout io.Writer
+	stderr io.Writer
+	// primaryABI is set if split APKs are built per ABI, its APK is exported as the single file output
+	primaryABI string
+	// sizeAnalysis is set if the app is built with --analyze-size
+	sizeAnalysis *sizeAnalysisConfig
+	// universalAPK is set if a universal APK is generated from the exported app bundle
+	universalAPK *universalAPKConfig
+	// env are the environment variables of the build command, the Gradle settings of the Android build
+	env []string
+	// namer names the deployed artifacts, shared by the specs of a Step run
+	namer *artifactNamer
+	// artifactName are the values of the artifact name template variables, except the ABI
+	artifactName artifactNameVariables
+	// additionalArgs are generated flutter build arguments, appended to additionalParameters
+	additionalArgs []string
+}
+
+// ExportOutputs finds the artifacts of the build, exports them and analyzes their size.
+func (spec BuildSpecification) ExportOutputs() error {
+	fmt.Println()
+	log.Infof("Export " + spec.displayName + " artifact")
+
+	matches, err := spec.artifactMatches()
+	if err != nil {
+		return fmt.Errorf("failed to find artifacts: %s", err)
+	}
+
+	artifacts, err := spec.dropStaleArtifacts(matchedArtifacts(spec.platformOutputType, matches))
+	if err != nil {
+		return err
+	}
+	if len(artifacts) < 1 {
+		return spec.missingArtifactsError(matches)
+	}
+
+	if err := spec.exportArtifacts(artifacts); err != nil {
+		return fmt.Errorf("failed to export %s artifacts: %s", spec.displayName, err)
+	}
+
+	if spec.sizeAnalysis != nil {
+		fmt.Println()
+		log.Infof("Analyze " + spec.displayName + " size")
+
+		if err := spec.analyzeSize(); err != nil {
+			return fmt.Errorf("size analysis of %s failed: %s", spec.displayName, err)
+		}
+	}
+	return nil
+}
+
+func (spec BuildSpecification) exportArtifacts(artifacts []string) error {
+	switch spec.platformOutputType {
+	case OutputTypeAPK:
+		return spec.exportAndroidArtifacts(OutputTypeAPK, artifacts)
+	case OutputTypeAppBundle:
+		return spec.exportAndroidArtifacts(OutputTypeAppBundle, artifacts)
+	case OutputTypeIOSApp:
+		return spec.exportIOSApp(artifacts)
+	case OutputTypeArchive:
+		return spec.exportIOSArchive(artifacts)
+	default:
+		return fmt.Errorf("unsupported platform for exporting artifacts: %s. Supported platforms: apk, appbundle, app, archive", spec.platformOutputType)
+	}
+}
+
+// analyzeSize reports the code size analysis of the build, exports it into the deploy dir and checks the size budgets.
+func (spec BuildSpecification) analyzeSize() error {
+	reportPth, err := findSizeAnalysisReport(spec.sizeAnalysis.codeSizeDir)
+	if err != nil {
+		return err
+	}
+	report, err := readSizeAnalysisReport(reportPth)
+	if err != nil {
+		return err
+	}
+
+	summary := summarizeSizeAnalysis(report)
+	for _, line := range strings.Split(strings.TrimSuffix(summary.String(), "\n"), "\n") {
+		log.Printf("%s", line)
+	}
+
+	platform := "ios"
+	if spec.platformOutputType == OutputTypeAPK || spec.platformOutputType == OutputTypeAppBundle {
+		platform = "android"
+	}
+	if err := spec.exportSizeAnalysis(reportPth, summary, platform); err != nil {
+		return err
+	}
+
+	if err := summary.check(spec.sizeAnalysis.budgets); err != nil {
+		return err
+	}
+
+	if spec.sizeAnalysis.baselinePath == "" {
+		return nil
+	}
+	return spec.compareSizeToBaseline(summary, platform)
+}
+
+// compareSizeToBaseline prints the size deltas compared to the baseline report and checks the size growth.
+func (spec BuildSpecification) compareSizeToBaseline(summary sizeSummary, platform string) error {
+	baselinePth, err := spec.baselineReportPath(platform)
+	if err != nil {
+		return err
+	}
+	baseline, err := readSizeAnalysisReport(baselinePth)
+	if err != nil {
+		return fmt.Errorf("failed to read size baseline: %s", err)
+	}
+	if baseline.Type != summary.artifactType {
+		log.Warnf("Size baseline (%s) is a %s analysis, not comparable with the %s analysis of this build", baselinePth, baseline.Type, summary.artifactType)
+		return nil
+	}
+
+	fmt.Println()
+	log.Infof("Compare " + spec.displayName + " size to " + baselinePth)
+
+	deltas := compareSizeSummaries(summarizeSizeAnalysis(baseline), summary)
+	for _, line := range strings.Split(strings.TrimSuffix(formatSizeDeltas(deltas), "\n"), "\n") {
+		log.Printf("%s", line)
+	}
+
+	return checkSizeGrowth(deltas, spec.sizeAnalysis.growthThreshold)
+}
+
+// DisplayName is the name of the built platform's app, for example "iOS app".
+func (spec BuildSpecification) DisplayName() string {
+	return spec.displayName
+}
+
+// ArtifactPaths returns the artifacts of the output type matching the output patterns.
+func (spec BuildSpecification) ArtifactPaths() ([]string, error) {
+	matches, err := spec.artifactMatches()
+	if err != nil {
+		return nil, err
+	}
+	return spec.dropStaleArtifacts(matchedArtifacts(spec.platformOutputType, matches))
+}
+
+// patternMatch is the paths an output pattern matched.
+type patternMatch struct {
+	pattern string
+	paths   []string
+}
+
+// artifactMatches returns the paths matched by every output pattern, directories for the iOS output types.
+func (spec BuildSpecification) artifactMatches() ([]patternMatch, error) {
+	isDir := !isAndroidOutputType(spec.platformOutputType)
+
+	var matches []patternMatch
+	for _, outputPathPattern := range spec.outputPathPatterns {
+		pths, err := FindPaths(spec.projectLocation, outputPathPattern, isDir)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, patternMatch{pattern: outputPathPattern, paths: pths})
+	}
+	return matches, nil
+}
+
+// matchedArtifacts returns the matched paths, Android artifacts of an other output type are dropped.
+func matchedArtifacts(outputType OutputType, matches []patternMatch) []string {
+	var artifacts []string
+	for _, match := range matches {
+		artifacts = append(artifacts, match.paths...)
+	}
+	if isAndroidOutputType(outputType) {
+		artifacts = FilterAndroidArtifactsBy(outputType, artifacts)
+	}
+	return artifacts
+}
+
+func isAndroidOutputType(outputType OutputType) bool {
+	return outputType == OutputTypeAPK || outputType == OutputTypeAppBundle
+}
+
+func (spec BuildSpecification) selectIOSArtifact(artifacts []string) (string, error) {
+	artifact, err := selectIOSArtifact(artifacts, spec.iosBundleID)
+	if err != nil {
+		return "", err
+	}
+
+	if len(artifacts) > 1 {
+		if spec.iosBundleID != "" {
+			log.Warnf("- Multiple artifacts found: %v, exporting the most recent one with bundle identifier %s: %s", artifacts, spec.iosBundleID, artifact)
+		} else {
+			log.Warnf("- Multiple artifacts found: %v, exporting the most recent one: %s", artifacts, artifact)
+		}
+	}
+	return artifact, nil
+}
+
+// deployPath returns the path of the artifact in the deploy dir, see artifactNamer.deployPath.
+func (spec BuildSpecification) deployPath(artifact, defaultName, ext, abi string) (string, error) {
+	vars := spec.artifactName
+	vars.abi = abi
+	return spec.artifactNamer().deployPath(artifact, defaultName, ext, vars)
+}
+
+func (spec BuildSpecification) artifactNamer() *artifactNamer {
+	if spec.namer == nil {
+		return newArtifactNamer("", spec.deployDir)
+	}
+	return spec.namer
+}
+
+func (spec BuildSpecification) exportIOSApp(artifacts []string) error {
+	artifact, err := spec.selectIOSArtifact(artifacts)
+	if err != nil {
+		return err
+	}
+
+	zipPath, err := spec.deployPath(artifact, filepath.Base(artifact)+".zip", ".app.zip", "")
+	if err != nil {
+		return err
+	}
+	if err := ziputil.ZipDir(artifact, zipPath, false); err != nil {
+		return err
+	}
+	log.Donef("- $BITRISE_DEPLOY_DIR/" + filepath.Base(zipPath))
+
+	if err := spec.exporter.ExportEnv("BITRISE_APP_DIR_PATH", artifact); err != nil {
+		return err
+	}
+	log.Donef("- $BITRISE_APP_DIR_PATH: " + artifact)
+
+	return spec.inspectIOSArtifact(artifact)
+}
+
+func (spec BuildSpecification) exportIOSArchive(artifacts []string) error {
+	artifact, err := spec.selectIOSArtifact(artifacts)
+	if err != nil {
+		return err
+	}
+
+	zipPath, err := spec.deployPath(artifact, filepath.Base(artifact)+".zip", ".xcarchive.zip", "")
+	if err != nil {
+		return err
+	}
+	if err := ziputil.ZipDir(artifact, zipPath, false); err != nil {
+		return err
+	}
+	log.Donef("- $BITRISE_DEPLOY_DIR/" + filepath.Base(zipPath))
+
+	if err := spec.exporter.ExportEnv("BITRISE_XCARCHIVE_PATH", artifact); err != nil {
+		return err
+	}
+	log.Donef("- $BITRISE_XCARCHIVE_PATH: " + artifact)
+
+	if err := spec.exporter.ExportEnv("BITRISE_XCARCHIVE_ZIP_PATH", zipPath); err != nil {
+		return err
+	}
+	log.Donef("- BITRISE_XCARCHIVE_ZIP_PATH: " + zipPath)
+
+	return spec.inspectIOSArtifact(artifact)
+}
+
+// inspectIOSArtifact logs and exports the metadata of the exported iOS artifact.
+func (spec BuildSpecification) inspectIOSArtifact(artifact string) error {
+	fmt.Println()
+	log.Infof("Inspect " + spec.displayName + " artifact")
+
+	info, err := inspectIOSArtifact(artifact)
+	if err != nil {
+		log.Warnf("Failed to inspect %s: %s", artifact, err)
+		return nil
+	}
+	log.Printf("- %s", filepath.Base(artifact))
+	info.print()
+
+	return exportIOSArtifactInfo(spec.exporter, info)
+}
+
+func (spec BuildSpecification) exportAndroidArtifacts(androidOutputType OutputType, artifacts []string) error {
+	artifacts = FilterAndroidArtifactsBy(androidOutputType, artifacts)
+	if androidOutputType == OutputTypeAPK && spec.primaryABI != "" {
+		var err error
+		if artifacts, err = orderPrimaryABILast(artifacts, spec.primaryABI); err != nil {
+			return err
+		}
+	}
+
+	var singleFileOutputEnvName string
+	var multipleFileOutputEnvName string
+	switch spec.platformOutputType {
+	case "appbundle":
+		singleFileOutputEnvName = "BITRISE_AAB_PATH"
+		multipleFileOutputEnvName = "BITRISE_AAB_PATH_LIST"
+	default:
+		singleFileOutputEnvName = "BITRISE_APK_PATH"
+		multipleFileOutputEnvName = "BITRISE_APK_PATH_LIST"
+	}
+
+	var deployedFiles []string
+	for _, path := range artifacts {
+		deployedFilePath, err := spec.deployPath(path, filepath.Base(path), filepath.Ext(path), splitAPKABI(path))
+		if err != nil {
+			return err
+		}
+
+		if err := spec.exporter.ExportFile(path, deployedFilePath, singleFileOutputEnvName); err != nil {
+			return err
+		}
+		deployedFiles = append(deployedFiles, deployedFilePath)
+	}
+	if err := spec.exporter.ExportEnv(multipleFileOutputEnvName, strings.Join(deployedFiles, "\n")); err != nil {
+		return err
+	}
+
+	deployedSingleFile := ""
+	if len(deployedFiles) > 0 {
+		deployedSingleFile = deployedFiles[len(deployedFiles)-1]
+	}
+
+	log.Donef("- " + singleFileOutputEnvName + ": " + deployedSingleFile)
+	log.Donef("- " + multipleFileOutputEnvName + ": " + strings.Join(deployedFiles, "|"))
+
+	if androidOutputType == OutputTypeAPK && spec.primaryABI != "" {
+		if err := spec.exportSplitAPKPaths(artifacts, deployedFiles); err != nil {
+			return err
+		}
+	}
+
+	if err := spec.inspectAndroidArtifacts(artifacts); err != nil {
+		return err
+	}
+
+	if androidOutputType == OutputTypeAppBundle && spec.universalAPK != nil && len(artifacts) > 0 {
+		return spec.exportUniversalAPK(artifacts[len(artifacts)-1])
+	}
+	return nil
+}
+
+// exportUniversalAPK generates the universal APK of the app bundle with bundletool and exports it as BITRISE_APK_PATH
+// and in BITRISE_APK_PATH_LIST.
+func (spec BuildSpecification) exportUniversalAPK(aabPath string) error {
+	fmt.Println()
+	log.Infof("Generate universal APK from " + filepath.Base(aabPath))
+
+	apkName := strings.TrimSuffix(filepath.Base(aabPath), filepath.Ext(aabPath)) + "-universal.apk"
+	apkPath := filepath.Join(filepath.Dir(aabPath), apkName)
+	if err := buildUniversalAPK(spec.runner, *spec.universalAPK, aabPath, apkPath); err != nil {
+		return err
+	}
+
+	deployedFilePath, err := spec.deployPath(apkPath, apkName, ".apk", "universal")
+	if err != nil {
+		return err
+	}
+	if err := spec.exporter.ExportFile(apkPath, deployedFilePath, "BITRISE_APK_PATH"); err != nil {
+		return err
+	}
+	if err := spec.exporter.ExportEnv("BITRISE_APK_PATH_LIST", deployedFilePath); err != nil {
+		return err
+	}
+	log.Donef("- BITRISE_APK_PATH: " + deployedFilePath)
+	log.Donef("- BITRISE_APK_PATH_LIST: " + deployedFilePath)
+	return nil
+}
+
+// exportSplitAPKPaths exports the deployed path of every split APK in a per ABI output.
+func (spec BuildSpecification) exportSplitAPKPaths(artifacts, deployedFiles []string) error {
+	for i, pth := range deployedFiles {
+		abi := splitAPKABI(artifacts[i])
+		if abi == "" {
+			continue
+		}
+
+		envName := splitAPKOutputEnvName(abi)
+		if err := spec.exporter.ExportEnv(envName, pth); err != nil {
+			return err
+		}
+		log.Donef("- " + envName + ": " + pth)
+	}
+	return nil
+}
+
+// inspectAndroidArtifacts logs the package metadata of every artifact, verifies them against the expected values
+// and exports the metadata of the artifact exported as the single file output.
+func (spec BuildSpecification) inspectAndroidArtifacts(artifacts []string) error {
+	if len(artifacts) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	log.Infof("Inspect " + spec.displayName + " artifacts")
+
+	for i, artifact := range artifacts {
+		log.Printf("- %s", filepath.Base(artifact))
+
+		info, err := inspectAndroidArtifact(artifact)
+		if err != nil {
+			if !spec.androidExpectations.isEmpty() {
+				return err
+			}
+			log.Warnf("  Failed to inspect artifact: %s", err)
+			continue
+		}
+		info.print()
+
+		expectations := spec.androidExpectations
+		if spec.primaryABI != "" {
+			expectations = expectations.forSplitAPK(splitAPKABI(artifact))
+		}
+		if err := info.verify(expectations); err != nil {
+			return fmt.Errorf("%s does not match the expected values: %s", filepath.Base(artifact), err)
+		}
+
+		if i == len(artifacts)-1 {
+			if err := exportAndroidArtifactInfo(spec.exporter, info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FilterAndroidArtifactsBy drops the artifacts which are not of the Android output type.
+func FilterAndroidArtifactsBy(androidOutputType OutputType, artifacts []string) []string {
+	var index int
+	for _, artifact := range artifacts {
+		switch androidOutputType {
+		case OutputTypeAPK:
+			if path.Ext(artifact) != ".apk" {
+				log.Debugf("Artifact (%s) found by output patterns, but it's not the selected output type (%s) - Skip", artifact, androidOutputType)
+				continue // drop artifact
+			}
+		case OutputTypeAppBundle:
+			if path.Ext(artifact) != ".aab" {
+				log.Debugf("Artifact (%s) found by output patterns, but it's not the selected output type (%s) - Skip", artifact, androidOutputType)
+				continue // drop artifact
+			}
+		}
+		artifacts[index] = artifact
+		index++
+	}
+	return artifacts[:index]
+}
+
+func (spec BuildSpecification) buildable(platform string) bool {
+	return sliceutil.IsStringInSlice(platform, spec.platformSelectors)
+}
+
+// FindPaths returns the files (or directories if dir is set) under location matching outputPathPattern.
+// Directories which cannot contain matching paths are not walked.
+func FindPaths(location string, outputPathPattern string, dir bool) (out []string, err error) {
+	pattern, err := newOutputPattern(location, outputPathPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(location, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		if entry.IsDir() && path != location && !pattern.mayMatchIn(path) {
+			return filepath.SkipDir
+		}
+
+		if !entry.IsDir() == dir || !pattern.match(path) {
+			return nil
+		}
+
+		out = append(out, path)
+		return nil
+	})
+	if len(out) == 0 && err == nil {
+		log.Debugf("couldn't find output artifact on path: " + filepath.Join(location, outputPathPattern))
+	}
+	return
+}
+
+// dropStaleArtifacts drops the artifacts last modified before the build started, as stale outputs of a previous build.
+// Returns an error naming the stale artifacts if the build produced none of the artifacts.
+func (spec BuildSpecification) dropStaleArtifacts(artifacts []string) ([]string, error) {
+	if spec.buildStartTime.IsZero() {
+		return artifacts, nil
+	}
+	// Some file systems store modification times with a one second precision
+	notBefore := spec.buildStartTime.Truncate(time.Second)
+
+	var fresh, stale []string
+	for _, artifact := range artifacts {
+		info, err := os.Stat(artifact)
+		if err != nil || !artifactModTime(artifact, info).Before(notBefore) {
+			fresh = append(fresh, artifact)
+		} else {
+			stale = append(stale, artifact)
+		}
+	}
+
+	if len(fresh) == 0 && len(stale) > 0 {
+		return nil, fmt.Errorf("every matching artifact was last modified before the build started, they are outputs of a previous build: %s", strings.Join(stale, ", "))
+	}
+	for _, artifact := range stale {
+		log.Warnf("Artifact (%s) was last modified before the build started - Skip stale artifact", artifact)
+	}
+	return fresh, nil
+}
+
+// artifactModTime returns the modification time of the artifact.
+// A directory's modification time changes only when entries are added or removed,
+// so for bundles (.app, .xcarchive) the top level Info.plist's modification time is used if it is newer.
+func artifactModTime(pth string, info os.FileInfo) time.Time {
+	modTime := info.ModTime()
+	if !info.IsDir() {
+		return modTime
+	}
+
+	if plistInfo, err := os.Stat(filepath.Join(pth, "Info.plist")); err == nil && plistInfo.ModTime().After(modTime) {
+		return plistInfo.ModTime()
+	}
+	return modTime
+}
+
+// Build runs flutter build, retrying it as configured.
+func (spec BuildSpecification) Build() error {
+	args, err := spec.buildArgs()
+	if err != nil {
+		return err
+	}
+
+	spec.logf(colorstring.NoColorf, "")
+	for _, env := range spec.env {
+		spec.logf(colorstring.NoColorf, "%s", printableGradleEnv(env))
+	}
+	spec.logf(colorstring.Greenf, "$ %s", spec.printableCommand(args))
+	spec.logf(colorstring.NoColorf, "")
+
+	maxAttempts := spec.retryPolicy.attempts()
+	for attempt := 1; ; attempt++ {
+		if maxAttempts > 1 {
+			spec.logf(colorstring.Bluef, "Build attempt %d/%d", attempt, maxAttempts)
+		}
+
+		output, err := spec.runBuildCommand(args)
+		if err == nil {
+			if maxAttempts > 1 {
+				spec.logf(colorstring.Greenf, "Build attempt %d/%d succeeded", attempt, maxAttempts)
+			}
+			return nil
+		}
+
+		if spec.platformOutputType == OutputTypeIOSApp {
+			if strings.Contains(strings.ToLower(output), "code signing is required") {
+				return ErrCodeSign
+			}
+		}
+
+		if maxAttempts == 1 {
+			return err
+		}
+
+		retryable, reason := spec.retryPolicy.classify(output)
+		spec.logf(colorstring.Yellowf, "Build attempt %d/%d failed: %s (%s)", attempt, maxAttempts, err, reason)
+		if !retryable || attempt == maxAttempts {
+			return err
+		}
+	}
+}
+
+// buildArgs returns the flutter build command arguments.
+func (spec BuildSpecification) buildArgs() ([]string, error) {
+	paramSlice, err := shellquote.Split(spec.additionalParameters)
+	if err != nil {
+		return nil, err
+	}
+
+	var platformCmd string
+	switch spec.platformOutputType {
+	case OutputTypeIOSApp:
+		platformCmd = "ios" // $ flutter build ios -> .app output
+	case OutputTypeArchive:
+		platformCmd = "ipa" // $ flutter build ipa -> .xcarchive output
+	default:
+		platformCmd = string(spec.platformOutputType)
+	}
+
+	paramSlice = append(paramSlice, spec.additionalArgs...)
+
+	if spec.platformOutputType == OutputTypeIOSApp {
+		paramSlice = append(paramSlice, "--no-codesign")
+	}
+
+	return append([]string{"build", platformCmd}, paramSlice...), nil
+}
+
+// printableCommand returns the flutter command line with the given arguments, with the dart define values redacted.
+func (spec BuildSpecification) printableCommand(args []string) string {
+	return printableCommandArgs(append([]string{"flutter"}, args...))
+}
+
+// outputWriters returns the writers of the build output, os.Stdout and os.Stderr if they are not set.
+func (spec BuildSpecification) outputWriters() (io.Writer, io.Writer) {
+	stdout, stderr := spec.stdout, spec.stderr
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	return stdout, stderr
+}
+
+// logf writes a log line of the build into the build output, so that it is prefixed or buffered
+// together with the output of concurrent builds. colorf is the color of the log level, see log.Printf.
+func (spec BuildSpecification) logf(colorf colorstring.ColorfFunc, format string, v ...interface{}) {
+	stdout, _ := spec.outputWriters()
+	if _, err := fmt.Fprintln(stdout, colorf(format, v...)); err != nil {
+		log.Warnf("Failed to print %s build log: %s", spec.displayName, err)
+	}
+}
+
+// runBuildCommand runs flutter with the given arguments and returns the tail of its combined output.
+func (spec BuildSpecification) runBuildCommand(args []string) (string, error) {
+	stdout, stderr := spec.outputWriters()
+
+	var output outputTail
+	buildCmd := Command{
+		Name:     "flutter",
+		Args:     args,
+		Dir:      spec.projectLocation,
+		Env:      spec.env,
+		Stdout:   io.MultiWriter(stdout, &output),
+		Stderr:   io.MultiWriter(stderr, &output),
+		Timeouts: spec.timeouts,
+	}
+
+	if spec.platformOutputType == OutputTypeIOSApp || spec.platformOutputType == OutputTypeArchive {
+		buildCmd.Stdin = strings.NewReader("a") // if the CLI asks to input the selected identity we force it to be aborted
+	}
+
+	err := spec.runner.Run(buildCmd)
+	return output.String(), err
+}
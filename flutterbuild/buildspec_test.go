@@ -0,0 +1,85 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_filterAndroidArtifactsBy(t *testing.T) {
+	tests := []struct {
+		name              string
+		androidOutputType OutputType
+		artifacts         []string
+		want              []string
+	}{
+		{
+			name:              "Filter APK",
+			androidOutputType: OutputTypeAPK,
+			artifacts: []string{
+				"test.apk",
+				"test_2.apk",
+				"test.aab",
+			},
+			want: []string{
+				"test.apk",
+				"test_2.apk",
+			},
+		},
+		{
+			name:              "Filter AAB",
+			androidOutputType: OutputTypeAppBundle,
+			artifacts: []string{
+				"test.apk",
+				"test_2.apk",
+				"test.aab",
+				"test_2.aab",
+			},
+			want: []string{
+				"test.aab",
+				"test_2.aab",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterAndroidArtifactsBy(tt.androidOutputType, tt.artifacts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterAndroidArtifactsBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_findPaths(t *testing.T) {
+	projectDir := t.TempDir()
+	apkDir := filepath.Join(projectDir, "build", "app", "outputs", "apk", "release")
+	require.NoError(t, os.MkdirAll(apkDir, 0755))
+
+	staleAPK := filepath.Join(apkDir, "app-staging-release.apk")
+	require.NoError(t, os.WriteFile(staleAPK, []byte{}, 0644))
+	buildStartTime := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(staleAPK, buildStartTime.Add(-time.Hour), buildStartTime.Add(-time.Hour)))
+
+	freshAPK := filepath.Join(apkDir, "app-release.apk")
+	require.NoError(t, os.WriteFile(freshAPK, []byte{}, 0644))
+
+	got, err := FindPaths(projectDir, "*build/app/outputs/apk/*/*.apk", false)
+	require.NoError(t, err)
+	require.Equal(t, []string{freshAPK, staleAPK}, got)
+
+	spec := BuildSpecification{buildStartTime: buildStartTime}
+	artifacts, err := spec.dropStaleArtifacts(got)
+	require.NoError(t, err)
+	require.Equal(t, []string{freshAPK}, artifacts)
+
+	_, err = spec.dropStaleArtifacts([]string{staleAPK})
+	require.EqualError(t, err, "every matching artifact was last modified before the build started, they are outputs of a previous build: "+staleAPK)
+
+	artifacts, err = BuildSpecification{}.dropStaleArtifacts(got)
+	require.NoError(t, err)
+	require.Equal(t, got, artifacts)
+}
@@ -0,0 +1,119 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// pipeCloseDelay is how long a killed build's output pipes are waited for,
+// descendants which left the process group may keep them open.
+const pipeCloseDelay = 10 * time.Second
+
+// Timeouts limits the run time of a build command, zero values disable the limits.
+type Timeouts struct {
+	// Timeout is the maximum duration of the build
+	Timeout time.Duration
+	// InactivityTimeout is the maximum duration without any stdout or stderr output
+	InactivityTimeout time.Duration
+}
+
+// checkInterval returns how often the timeouts are checked, a tenth of the shortest timeout but at most a second.
+func (timeouts Timeouts) checkInterval() time.Duration {
+	interval := time.Second
+	for _, timeout := range []time.Duration{timeouts.Timeout, timeouts.InactivityTimeout} {
+		if timeout > 0 && timeout/10 < interval {
+			interval = timeout / 10
+		}
+	}
+	return interval
+}
+
+// activityTracker records the time of the last output written through its writers.
+type activityTracker struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (tracker *activityTracker) touch() {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	tracker.last = time.Now()
+}
+
+func (tracker *activityTracker) since() time.Duration {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return time.Since(tracker.last)
+}
+
+func (tracker *activityTracker) writer(w io.Writer) io.Writer {
+	if w == nil {
+		w = io.Discard
+	}
+	return activityWriter{tracker: tracker, w: w}
+}
+
+type activityWriter struct {
+	tracker *activityTracker
+	w       io.Writer
+}
+
+func (w activityWriter) Write(p []byte) (int, error) {
+	w.tracker.touch()
+	return w.w.Write(p)
+}
+
+// runWithTimeouts runs cmd in its own process group and kills the whole group if the build
+// takes longer than the timeout, or does not write any output for longer than the inactivity timeout.
+func runWithTimeouts(cmd *exec.Cmd, timeouts Timeouts) error {
+	if timeouts == (Timeouts{}) {
+		return cmd.Run()
+	}
+
+	tracker := &activityTracker{last: time.Now()}
+	cmd.Stdout = tracker.writer(cmd.Stdout)
+	cmd.Stderr = tracker.writer(cmd.Stderr)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.WaitDelay = pipeCloseDelay
+
+	startTime := time.Now()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(timeouts.checkInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			var timeoutErr error
+			if timeouts.Timeout > 0 && time.Since(startTime) > timeouts.Timeout {
+				timeoutErr = fmt.Errorf("build timed out after %s", timeouts.Timeout)
+			} else if timeouts.InactivityTimeout > 0 && tracker.since() > timeouts.InactivityTimeout {
+				timeoutErr = fmt.Errorf("build considered stuck, no output for %s", timeouts.InactivityTimeout)
+			}
+			if timeoutErr == nil {
+				continue
+			}
+
+			// A negative pid signals the whole process group
+			if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+				return fmt.Errorf("%s, failed to kill the build process: %s", timeoutErr, err)
+			}
+			<-done
+			return timeoutErr
+		}
+	}
+}
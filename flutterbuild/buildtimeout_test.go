@@ -0,0 +1,63 @@
+package flutterbuild
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runWithTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		timeouts Timeouts
+		wantErr  string
+	}{
+		{
+			name:   "no timeouts",
+			script: "echo done",
+		},
+		{
+			name:     "finishes in time",
+			script:   "echo done",
+			timeouts: Timeouts{Timeout: 10 * time.Second, InactivityTimeout: 10 * time.Second},
+		},
+		{
+			name:     "timeout kills the process tree",
+			script:   "echo start; sleep 30 & wait",
+			timeouts: Timeouts{Timeout: 500 * time.Millisecond},
+			wantErr:  "build timed out after 500ms",
+		},
+		{
+			name:     "inactivity timeout",
+			script:   "echo start; sleep 30",
+			timeouts: Timeouts{Timeout: 20 * time.Second, InactivityTimeout: 500 * time.Millisecond},
+			wantErr:  "build considered stuck, no output for 500ms",
+		},
+		{
+			name:     "output resets the inactivity timeout",
+			script:   "for i in 1 2 3 4 5; do echo $i; sleep 0.2; done",
+			timeouts: Timeouts{InactivityTimeout: 700 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			cmd := exec.Command("sh", "-c", tt.script)
+			cmd.Stdout = &stdout
+
+			startTime := time.Now()
+			err := runWithTimeouts(cmd, tt.timeouts)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				require.Less(t, time.Since(startTime), 10*time.Second)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// BuildNumberSource is where the build number (--build-number) comes from, the zero value does not set it.
+type BuildNumberSource string
+
+const (
+	// BuildNumberSourceNone does not set the build number
+	BuildNumberSourceNone BuildNumberSource = "none"
+	// BuildNumberSourceExplicit uses the build number input
+	BuildNumberSourceExplicit BuildNumberSource = "explicit"
+	// BuildNumberSourceBitrise uses $BITRISE_BUILD_NUMBER plus the offset
+	BuildNumberSourceBitrise BuildNumberSource = "bitrise_build_number"
+	// BuildNumberSourceGitCommitCount uses the commit count of the git HEAD plus the offset
+	BuildNumberSourceGitCommitCount BuildNumberSource = "git_commit_count"
+)
+
+// BuildNameSource is where the build name (--build-name) comes from, the zero value does not set it.
+type BuildNameSource string
+
+const (
+	// BuildNameSourceNone does not set the build name
+	BuildNameSourceNone BuildNameSource = "none"
+	// BuildNameSourcePubspec uses the version name of pubspec.yaml
+	BuildNameSourcePubspec BuildNameSource = "pubspec"
+	// BuildNameSourceGitTag uses the latest git tag, without a v prefix
+	BuildNameSourceGitTag BuildNameSource = "git_tag"
+)
+
+// resolveBuildNumber returns the build number (--build-number) from the selected source,
+// the offset is added to the Bitrise build number and to the git commit count.
+func resolveBuildNumber(runner CommandRunner, source BuildNumberSource, explicit string, offset int, projectDir string) (string, error) {
+	var value string
+	switch source {
+	case "", BuildNumberSourceNone:
+		return "", nil
+	case BuildNumberSourceExplicit:
+		if _, err := strconv.Atoi(explicit); err != nil {
+			return "", fmt.Errorf("build number (%s) is not an integer", explicit)
+		}
+		return explicit, nil
+	case BuildNumberSourceBitrise:
+		value = os.Getenv("BITRISE_BUILD_NUMBER")
+		if value == "" {
+			return "", fmt.Errorf("BITRISE_BUILD_NUMBER is not set")
+		}
+	case BuildNumberSourceGitCommitCount:
+		// The commit count of a shallow clone is its depth, which would lower the build number
+		shallow, err := runner.Output(projectDir, "git", "rev-parse", "--is-shallow-repository")
+		if err != nil {
+			return "", fmt.Errorf("failed to check if the git repository is a shallow clone: %s: %s", err, shallow)
+		}
+		if shallow == "true" {
+			return "", fmt.Errorf("the git repository is a shallow clone, its commit count is the clone depth: clone the full history (for example set the clone depth to 0) or use another build number source")
+		}
+
+		out, err := runner.Output(projectDir, "git", "rev-list", "--count", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("failed to count git commits: %s: %s", err, out)
+		}
+		value = out
+	default:
+		return "", fmt.Errorf("unsupported build number source: %s", source)
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return "", fmt.Errorf("build number (%s) from %s is not an integer", value, source)
+	}
+	return strconv.Itoa(number + offset), nil
+}
+
+// resolveBuildName returns the build name (--build-name) from the selected source.
+func resolveBuildName(runner CommandRunner, source BuildNameSource, appPubspec pubspec, projectDir string) (string, error) {
+	switch source {
+	case "", BuildNameSourceNone:
+		return "", nil
+	case BuildNameSourcePubspec:
+		name, _ := splitPubspecVersion(appPubspec.Version)
+		if name == "" {
+			return "", fmt.Errorf("version is not set in pubspec.yaml")
+		}
+		return name, nil
+	case BuildNameSourceGitTag:
+		out, err := runner.Output(projectDir, "git", "describe", "--tags", "--abbrev=0")
+		if err != nil {
+			return "", fmt.Errorf("failed to find the latest git tag: %s: %s", err, out)
+		}
+		return buildNameFromTag(out), nil
+	default:
+		return "", fmt.Errorf("unsupported build name source: %s", source)
+	}
+}
+
+// buildNameFromTag strips the common `v` prefix of version tags, e.g. v1.2.3 -> 1.2.3.
+func buildNameFromTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if len(tag) > 1 && (tag[0] == 'v' || tag[0] == 'V') && tag[1] >= '0' && tag[1] <= '9' {
+		return tag[1:]
+	}
+	return tag
+}
+
+func buildVersionArgs(buildName, buildNumber string) []string {
+	var args []string
+	if buildName != "" {
+		args = append(args, "--build-name="+buildName)
+	}
+	if buildNumber != "" {
+		args = append(args, "--build-number="+buildNumber)
+	}
+	return args
+}
+
+func exportBuildVersion(exporter OutputExporter, buildName, buildNumber string) error {
+	if buildName != "" {
+		if err := exporter.ExportEnv("FLUTTER_BUILD_NAME", buildName); err != nil {
+			return err
+		}
+		log.Donef("- FLUTTER_BUILD_NAME: " + buildName)
+	}
+	if buildNumber != "" {
+		if err := exporter.ExportEnv("FLUTTER_BUILD_NUMBER", buildNumber); err != nil {
+			return err
+		}
+		log.Donef("- FLUTTER_BUILD_NUMBER: " + buildNumber)
+	}
+	return nil
+}
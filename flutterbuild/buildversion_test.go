@@ -0,0 +1,80 @@
+package flutterbuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveBuildNumber(t *testing.T) {
+	t.Setenv("BITRISE_BUILD_NUMBER", "42")
+
+	tests := []struct {
+		name     string
+		source   BuildNumberSource
+		explicit string
+		offset   int
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:   "none",
+			source: BuildNumberSourceNone,
+			want:   "",
+		},
+		{
+			name:     "explicit",
+			source:   BuildNumberSourceExplicit,
+			explicit: "7",
+			offset:   100,
+			want:     "7",
+		},
+		{
+			name:     "explicit not an integer",
+			source:   BuildNumberSourceExplicit,
+			explicit: "1.0",
+			wantErr:  true,
+		},
+		{
+			name:   "Bitrise build number with offset",
+			source: BuildNumberSourceBitrise,
+			offset: 1000,
+			want:   "1042",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveBuildNumber(execCommandRunner{}, tt.source, tt.explicit, tt.offset, t.TempDir())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_resolveBuildNumber_gitCommitCount(t *testing.T) {
+	got, err := resolveBuildNumber(&fakeFlutterRunner{t: t}, BuildNumberSourceGitCommitCount, "", 1, t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, "42", got)
+
+	_, err = resolveBuildNumber(&fakeFlutterRunner{t: t, shallow: true}, BuildNumberSourceGitCommitCount, "", 1, t.TempDir())
+	require.EqualError(t, err, "the git repository is a shallow clone, its commit count is the clone depth: clone the full history (for example set the clone depth to 0) or use another build number source")
+}
+
+func Test_resolveBuildName_pubspec(t *testing.T) {
+	got, err := resolveBuildName(execCommandRunner{}, BuildNameSourcePubspec, pubspec{Name: "app", Version: "1.2.3+4"}, t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, "1.2.3", got)
+
+	_, err = resolveBuildName(execCommandRunner{}, BuildNameSourcePubspec, pubspec{Name: "app"}, t.TempDir())
+	require.Error(t, err)
+}
+
+func Test_buildNameFromTag(t *testing.T) {
+	require.Equal(t, "1.2.3", buildNameFromTag("v1.2.3\n"))
+	require.Equal(t, "1.2.3", buildNameFromTag("1.2.3"))
+	require.Equal(t, "version-1", buildNameFromTag("version-1"))
+}
@@ -0,0 +1,158 @@
+package flutterbuild
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// keystoreConfig is the keystore used to sign the APKs generated by bundletool.
+type keystoreConfig struct {
+	url                string
+	password           string
+	alias              string
+	privateKeyPassword string
+}
+
+func (keystore keystoreConfig) validate() error {
+	if keystore.url == "" {
+		return nil
+	}
+	if keystore.password == "" {
+		return errors.New("keystore password is required if the keystore URL is set")
+	}
+	if keystore.alias == "" {
+		return errors.New("keystore alias is required if the keystore URL is set")
+	}
+	return nil
+}
+
+// universalAPKConfig configures generating a universal APK from the exported app bundle.
+type universalAPKConfig struct {
+	bundletoolPath string
+	keystore       keystoreConfig
+}
+
+// keystoreDownloadTimeout limits the download of a remote keystore, so that an unresponsive server does not hang the Step.
+const keystoreDownloadTimeout = 2 * time.Minute
+
+// downloadKeystore returns the local path of the keystore, remote (http, https) keystores are downloaded into dir.
+func downloadKeystore(keystoreURL, dir string) (string, error) {
+	if strings.HasPrefix(keystoreURL, "file://") {
+		return strings.TrimPrefix(keystoreURL, "file://"), nil
+	}
+	if !strings.HasPrefix(keystoreURL, "http://") && !strings.HasPrefix(keystoreURL, "https://") {
+		return keystoreURL, nil
+	}
+
+	client := http.Client{Timeout: keystoreDownloadTimeout}
+	resp, err := client.Get(keystoreURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to download keystore: %s", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("Failed to close keystore download response body: %s", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download keystore, status code: %d", resp.StatusCode)
+	}
+
+	pth := filepath.Join(dir, "keystore.jks")
+	f, err := os.Create(pth)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("failed to download keystore: %s", err)
+	}
+	return pth, f.Close()
+}
+
+// bundletoolBuildUniversalAPKsArgs returns the `bundletool build-apks --mode=universal` command arguments.
+func bundletoolBuildUniversalAPKsArgs(bundletoolPath, aabPath, apksPath, keystorePath string, keystore keystoreConfig) []string {
+	args := []string{"java", "-jar", bundletoolPath, "build-apks", "--mode=universal", "--bundle=" + aabPath, "--output=" + apksPath}
+	if keystorePath != "" {
+		args = append(args,
+			"--ks="+keystorePath,
+			"--ks-pass=pass:"+keystore.password,
+			"--ks-key-alias="+keystore.alias,
+		)
+		if keystore.privateKeyPassword != "" {
+			args = append(args, "--key-pass=pass:"+keystore.privateKeyPassword)
+		}
+	}
+	return args
+}
+
+// buildUniversalAPK generates the universal APK of the app bundle with bundletool into outputPath.
+func buildUniversalAPK(runner CommandRunner, cfg universalAPKConfig, aabPath, outputPath string) error {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("bundletool")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warnf("Failed to remove %s: %s", tmpDir, err)
+		}
+	}()
+
+	keystorePath := ""
+	if cfg.keystore.url != "" {
+		keystorePath, err = downloadKeystore(cfg.keystore.url, tmpDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	apksPath := filepath.Join(tmpDir, "universal.apks")
+	args := bundletoolBuildUniversalAPKsArgs(cfg.bundletoolPath, aabPath, apksPath, keystorePath, cfg.keystore)
+	cmd := Command{Name: args[0], Args: args[1:], Stdout: os.Stdout, Stderr: os.Stderr}
+
+	fmt.Println()
+	log.Donef("$ %s", printableCommandArgs(args))
+	fmt.Println()
+
+	if err := runner.Run(cmd); err != nil {
+		return fmt.Errorf("bundletool build-apks failed: %s", err)
+	}
+
+	return extractUniversalAPK(apksPath, outputPath)
+}
+
+// extractUniversalAPK extracts universal.apk from the APK set generated by bundletool.
+func extractUniversalAPK(apksPath, outputPath string) error {
+	reader, err := zip.OpenReader(apksPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %s", apksPath, err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Warnf("Failed to close %s: %s", apksPath, err)
+		}
+	}()
+
+	for _, file := range reader.File {
+		if file.Name != "universal.apk" {
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outputPath, content, 0644)
+	}
+	return fmt.Errorf("universal.apk not found in %s", apksPath)
+}
@@ -0,0 +1,56 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bundletoolBuildUniversalAPKsArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		keystorePath string
+		keystore     keystoreConfig
+		want         []string
+	}{
+		{
+			name: "debug signing",
+			want: []string{"java", "-jar", "bundletool.jar", "build-apks", "--mode=universal", "--bundle=app.aab", "--output=app.apks"},
+		},
+		{
+			name:         "keystore signing",
+			keystorePath: "release.jks",
+			keystore:     keystoreConfig{url: "file://release.jks", password: "storepass", alias: "key0", privateKeyPassword: "keypass"},
+			want: []string{"java", "-jar", "bundletool.jar", "build-apks", "--mode=universal", "--bundle=app.aab", "--output=app.apks",
+				"--ks=release.jks", "--ks-pass=pass:storepass", "--ks-key-alias=key0", "--key-pass=pass:keypass"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bundletoolBuildUniversalAPKsArgs("bundletool.jar", "app.aab", "app.apks", tt.keystorePath, tt.keystore)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_extractUniversalAPK(t *testing.T) {
+	dir := t.TempDir()
+
+	apksPth := filepath.Join(dir, "app.apks")
+	createTestZip(t, apksPth, map[string][]byte{
+		"toc.pb":        {},
+		"universal.apk": []byte("apk"),
+	})
+
+	outputPth := filepath.Join(dir, "app-universal.apk")
+	require.NoError(t, extractUniversalAPK(apksPth, outputPth))
+	content, err := os.ReadFile(outputPth)
+	require.NoError(t, err)
+	require.Equal(t, "apk", string(content))
+
+	missingPth := filepath.Join(dir, "missing.apks")
+	createTestZip(t, missingPth, map[string][]byte{"toc.pb": {}})
+	require.Error(t, extractUniversalAPK(missingPth, outputPth))
+}
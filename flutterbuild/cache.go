@@ -1,4 +1,4 @@
-package main
+package flutterbuild
 
 import (
 	"encoding/json"
@@ -15,54 +15,107 @@ import (
 	"github.com/bitrise-io/go-utils/sliceutil"
 )
 
-func cacheCocoapodsDeps(projectLocation string) error {
+// cacheItems are the paths to cache and to exclude from the cache.
+type cacheItems struct {
+	include []string
+	exclude []string
+}
+
+func (items cacheItems) isEmpty() bool {
+	return len(items.include) == 0 && len(items.exclude) == 0
+}
+
+// collectCacheItems runs every cache collector of the project, failing collectors are logged and skipped.
+func collectCacheItems(projectDir, workspaceDir string) cacheItems {
+	collectors := []struct {
+		name    string
+		collect func() (cacheItems, error)
+	}{
+		{name: "cocoapods", collect: func() (cacheItems, error) { return cocoapodsCacheItems(projectDir) }},
+		{name: "carthage", collect: func() (cacheItems, error) { return carthageCacheItems(projectDir) }},
+		{name: "android", collect: func() (cacheItems, error) { return androidCacheItems(projectDir) }},
+		{name: "flutter", collect: func() (cacheItems, error) { return flutterCacheItems(projectDir, workspaceDir) }},
+	}
+
+	var items cacheItems
+	for _, collector := range collectors {
+		collected, err := collector.collect()
+		if err != nil {
+			log.Warnf("Failed to collect %s cache, error: %s", collector.name, err)
+			continue
+		}
+		items.include = append(items.include, collected.include...)
+		items.exclude = append(items.exclude, collected.exclude...)
+	}
+	return items
+}
+
+// commitCacheItems exports the cache paths through the exporter.
+func commitCacheItems(exporter OutputExporter, items cacheItems) error {
+	if items.isEmpty() {
+		return nil
+	}
+
+	c := cache.Config{
+		VariableGetter:  cache.NewOSVariableGetter(),
+		VariableSetters: []cache.VariableSetter{cache.NewOSVariableSetter(), exporterVariableSetter{exporter: exporter}},
+	}.NewCache()
+	c.IncludePath(items.include...)
+	c.ExcludePath(items.exclude...)
+	if err := c.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cache paths: %s", err)
+	}
+	return nil
+}
+
+func cocoapodsCacheItems(projectLocation string) (cacheItems, error) {
 	iosDir, err := pathutil.AbsPath(filepath.Join(projectLocation, "ios"))
 	if err != nil {
-		return err
+		return cacheItems{}, err
 	}
 
 	podfileLockPth := filepath.Join(iosDir, "Podfile.lock")
 	if exist, err := pathutil.IsPathExists(podfileLockPth); err != nil {
-		return err
+		return cacheItems{}, err
 	} else if !exist {
-		return nil
+		return cacheItems{}, nil
 	}
 
-	podsCache := cache.New()
-	podsCache.IncludePath(fmt.Sprintf("%s -> %s", filepath.Join(iosDir, "Pods"), podfileLockPth))
-	return podsCache.Commit()
+	return cacheItems{include: []string{fmt.Sprintf("%s -> %s", filepath.Join(iosDir, "Pods"), podfileLockPth)}}, nil
 }
 
-func cacheCarthageDeps(projectDir string) error {
+func carthageCacheItems(projectDir string) (cacheItems, error) {
 	iosDir, err := pathutil.AbsPath(filepath.Join(projectDir, "ios"))
 	if err != nil {
-		return err
+		return cacheItems{}, err
 	}
 
 	cartfileResolvedPth := filepath.Join(iosDir, "Cartfile.resolved")
 	if exist, err := pathutil.IsPathExists(cartfileResolvedPth); err != nil {
-		return err
+		return cacheItems{}, err
 	} else if !exist {
-		return nil
+		return cacheItems{}, nil
 	}
 
-	carthageCache := cache.New()
-	carthageCache.IncludePath(fmt.Sprintf("%s -> %s", filepath.Join(iosDir, "Carthage"), cartfileResolvedPth))
-	return carthageCache.Commit()
+	return cacheItems{include: []string{fmt.Sprintf("%s -> %s", filepath.Join(iosDir, "Carthage"), cartfileResolvedPth)}}, nil
 }
 
-func cacheAndroidDeps(projectDir string) error {
+func androidCacheItems(projectDir string) (cacheItems, error) {
 	androidDir := filepath.Join(projectDir, "android")
 
 	exist, err := pathutil.IsDirExists(androidDir)
 	if err != nil {
-		return fmt.Errorf("failed to check if directory (%s) exists, error: %s", androidDir, err)
+		return cacheItems{}, fmt.Errorf("failed to check if directory (%s) exists, error: %s", androidDir, err)
 	}
 	if !exist {
-		return nil
+		return cacheItems{}, nil
 	}
 
-	return androidCache.Collect(androidDir, cache.LevelDeps)
+	includes, excludes, err := androidCache.NewAndroidGradleCacheItemCollector().Collect(androidDir, cache.LevelDeps)
+	if err != nil {
+		return cacheItems{}, err
+	}
+	return cacheItems{include: includes, exclude: excludes}, nil
 }
 
 func openFile(filepath string) (string, error) {
@@ -78,7 +131,7 @@ func openFile(filepath string) (string, error) {
 	return string(contents), nil
 }
 
-// parsePackageResolutionFile parses flutter package resolution file: `.package`
+// ParsePackageResolutionFile parses flutter package resolution file: `.package`
 // https://dart.dev/tools/pub/cmd/pub-get
 /* If there are any packages from git source the whole `git` directory is cached,
 as the contents of `.git` dir may be needed for package resolution.
@@ -114,7 +167,7 @@ The layout of .pub-cache:
             |- lib							→  The resolved package source code path for hosted packages
         …
 */
-func parsePackageResolutionFile(contents string) (map[string]url.URL, error) {
+func ParsePackageResolutionFile(contents string) (map[string]url.URL, error) {
 	// Both line seperators are supported, empty lines will be ignored
 	// https://github.com/lrhn/dep-pkgspec/blob/master/DEP-pkgspec.md#proposal
 	contents = strings.Replace(contents, "\r", "\n", -1)
@@ -150,7 +203,8 @@ func parsePackageResolutionFile(contents string) (map[string]url.URL, error) {
 	return packageToLocation, nil
 }
 
-func cacheableFlutterDepPaths(packageToLocation map[string]url.URL) ([]string, error) {
+// CacheableFlutterDepPaths returns the system dependency cache (.pub-cache) paths of the resolved packages.
+func CacheableFlutterDepPaths(packageToLocation map[string]url.URL) ([]string, error) {
 	var cachePaths []string
 	foundGitSourcePackages := false
 
@@ -209,26 +263,34 @@ func cacheableFlutterDepPaths(packageToLocation map[string]url.URL) ([]string, e
 	return cachePaths, nil
 }
 
-func cacheFlutterDeps(projectDir string) error {
-	packageToLocation, err := readOldPackageFormat(projectDir)
+// flutterCacheItems collects the pub dependencies of the project.
+// If the project is a member of a workspace (workspaceDir), the package resolution file
+// is looked up in the workspace root too, as pub workspaces resolve all members there.
+func flutterCacheItems(projectDir, workspaceDir string) (cacheItems, error) {
+	packageToLocation, err := readPackageResolution(projectDir)
+	if err != nil && workspaceDir != "" && workspaceDir != projectDir {
+		log.Debugf("Flutter dependency cache: %s, checking the workspace root (%s)", err, workspaceDir)
+		packageToLocation, err = readPackageResolution(workspaceDir)
+	}
 	if err != nil {
-		packageToLocation, err = readNewJSONFormat(projectDir)
-		if err != nil {
-			return err
-		}
+		return cacheItems{}, err
 	}
 
-	cachePaths, err := cacheableFlutterDepPaths(packageToLocation)
+	cachePaths, err := CacheableFlutterDepPaths(packageToLocation)
 	if err != nil {
-		return err
+		return cacheItems{}, err
 	}
 	log.Debugf("Marking Flutter dependency paths to be cached: %s", cachePaths)
 
-	pubCache := cache.New()
-	for _, path := range cachePaths {
-		pubCache.IncludePath(path)
+	return cacheItems{include: cachePaths}, nil
+}
+
+func readPackageResolution(projectDir string) (map[string]url.URL, error) {
+	packageToLocation, err := readOldPackageFormat(projectDir)
+	if err != nil {
+		return readNewJSONFormat(projectDir)
 	}
-	return pubCache.Commit()
+	return packageToLocation, nil
 }
 
 func readOldPackageFormat(projectDir string) (map[string]url.URL, error) {
@@ -238,7 +300,7 @@ func readOldPackageFormat(projectDir string) (map[string]url.URL, error) {
 		return map[string]url.URL{}, err
 	}
 
-	packageToLocation, err := parsePackageResolutionFile(contents)
+	packageToLocation, err := ParsePackageResolutionFile(contents)
 	if err != nil {
 		return map[string]url.URL{}, fmt.Errorf("failed to parse Flutter package resolution file, error: %s", err)
 	}
@@ -253,15 +315,25 @@ func readNewJSONFormat(projectDir string) (map[string]url.URL, error) {
 		return map[string]url.URL{}, err
 	}
 
-	packages, err := parseJSON(contents)
+	packages, err := ParsePackageConfig(contents)
 	if err != nil {
 		return map[string]url.URL{}, err
 	}
 
+	// Relative root URIs are resolved against the directory of package_config.json
+	configDir := filepath.Dir(packagePath)
+	for name, location := range packages {
+		if location.Scheme == "" && !filepath.IsAbs(location.Path) {
+			location.Path = filepath.Join(configDir, location.Path)
+			packages[name] = location
+		}
+	}
+
 	return packages, nil
 }
 
-func parseJSON(contents string) (map[string]url.URL, error) {
+// ParsePackageConfig parses the package configuration file (.dart_tool/package_config.json) of a resolved project.
+func ParsePackageConfig(contents string) (map[string]url.URL, error) {
 	type packageConfig struct {
 		Packages []struct {
 			Name       string `json:"name"`
@@ -1,8 +1,9 @@
-package main
+package flutterbuild
 
 import (
 	"github.com/stretchr/testify/assert"
 	"net/url"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -101,13 +102,13 @@ analyzer::invalid/ss`,
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parsePackageResolutionFile(tt.contents)
+			got, err := ParsePackageResolutionFile(tt.contents)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("parsePackageResolutionFile() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ParsePackageResolutionFile() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("parsePackageResolutionFile() = %v, want %v", got, tt.want)
+				t.Errorf("ParsePackageResolutionFile() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -165,13 +166,13 @@ func Test_cacheableFlutterDepPaths(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := cacheableFlutterDepPaths(tt.packageToLocation)
+			got, err := CacheableFlutterDepPaths(tt.packageToLocation)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("cacheableFlutterDepPaths() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("CacheableFlutterDepPaths() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("cacheableFlutterDepPaths() = %v, want %v", got, tt.want)
+				t.Errorf("CacheableFlutterDepPaths() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -194,7 +195,7 @@ func TestJSONFormatParsing(t *testing.T) {
   "generatorVersion": "2.15.1"
 }
 `
-	result, err := parseJSON(testData)
+	result, err := ParsePackageConfig(testData)
 	require.NoError(t, err)
 
 	fileURL, err := url.Parse(filepath.Join("file:///path/to/file", "lib/"))
@@ -205,3 +206,34 @@ func TestJSONFormatParsing(t *testing.T) {
 	}
 	assert.Equal(t, expected, result)
 }
+
+func Test_flutterCacheItems_workspaceRoot(t *testing.T) {
+	workspaceDir := t.TempDir()
+	projectDir := filepath.Join(workspaceDir, "packages", "app")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	packageConfig := `{
+  "configVersion": 2,
+  "packages": [
+    {
+      "name": "yaml",
+      "rootUri": "file:///Users/vagrant/.pub-cache/hosted/pub.dev/yaml-3.1.2",
+      "packageUri": "lib/"
+    },
+    {
+      "name": "core",
+      "rootUri": "../packages/core",
+      "packageUri": "lib/"
+    }
+  ]
+}`
+	require.NoError(t, os.MkdirAll(filepath.Join(workspaceDir, ".dart_tool"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspaceDir, ".dart_tool", "package_config.json"), []byte(packageConfig), 0644))
+
+	_, err := flutterCacheItems(projectDir, "")
+	require.Error(t, err)
+
+	items, err := flutterCacheItems(projectDir, workspaceDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/Users/vagrant/.pub-cache/hosted/pub.dev/yaml-3.1.2"}, items.include)
+}
@@ -0,0 +1,44 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// CleanMode is how the outputs of previous builds are removed before building, the zero value does not clean.
+type CleanMode string
+
+const (
+	// CleanModeNone does not clean
+	CleanModeNone CleanMode = "none"
+	// CleanModeFlutterClean runs flutter clean in the project
+	CleanModeFlutterClean CleanMode = "flutter_clean"
+	// CleanModeBuildDir removes the build directory of the project
+	CleanModeBuildDir CleanMode = "build_dir"
+)
+
+// cleanProject removes the outputs of previous builds, so that stale artifacts are not exported.
+func cleanProject(runner CommandRunner, projectLocation string, mode CleanMode) error {
+	switch mode {
+	case CleanModeFlutterClean:
+		cmd := Command{Name: "flutter", Args: []string{"clean"}, Dir: projectLocation, Stdout: os.Stdout, Stderr: os.Stderr}
+
+		fmt.Println()
+		log.Donef("$ %s", cmd.printableCommandArgs())
+		fmt.Println()
+
+		return runner.Run(cmd)
+	case CleanModeBuildDir:
+		buildDir := filepath.Join(projectLocation, "build")
+		log.Printf("- Removing %s", buildDir)
+		if err := os.RemoveAll(buildDir); err != nil {
+			return fmt.Errorf("failed to remove build directory (%s): %s", buildDir, err)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,130 @@
+package flutterbuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+const (
+	dartDefineFlag         = "--dart-define"
+	dartDefineFromFileFlag = "--dart-define-from-file"
+	redactedValue          = "[REDACTED]"
+)
+
+type dartDefine struct {
+	key   string
+	value string
+}
+
+// parseDartDefines parses the dart_defines input: a `KEY=VALUE` pair per line,
+// or just a `KEY`, in which case the value is read from the environment variable of the same name.
+func parseDartDefines(content string) ([]dartDefine, error) {
+	var defines []dartDefine
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid dart define, missing key: %s", line)
+		}
+
+		if !found {
+			envValue, ok := os.LookupEnv(key)
+			if !ok {
+				return nil, fmt.Errorf("dart define (%s) refers to an environment variable which is not set", key)
+			}
+			value = envValue
+		}
+
+		defines = append(defines, dartDefine{key: key, value: value})
+	}
+	return defines, nil
+}
+
+// readDartDefineFileValues returns the values defined in a `--dart-define-from-file` file (JSON or .env format).
+func readDartDefineFileValues(pth string) ([]string, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dart define file: %s", err)
+	}
+
+	var values []string
+	if filepath.Ext(pth) == ".json" {
+		var defines map[string]interface{}
+		if err := json.Unmarshal(content, &defines); err != nil {
+			return nil, fmt.Errorf("failed to parse dart define file (%s): %s", pth, err)
+		}
+		for _, value := range defines {
+			values = append(values, fmt.Sprintf("%v", value))
+		}
+		return values, nil
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		_, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("failed to parse dart define file (%s), invalid line: %s", pth, line)
+		}
+		values = append(values, strings.Trim(strings.TrimSpace(value), `"'`))
+	}
+	return values, nil
+}
+
+func dartDefineArgs(defines []dartDefine, fromFile string) []string {
+	var args []string
+	for _, define := range defines {
+		args = append(args, fmt.Sprintf("%s=%s=%s", dartDefineFlag, define.key, define.value))
+	}
+	if fromFile != "" {
+		args = append(args, fmt.Sprintf("%s=%s", dartDefineFromFileFlag, fromFile))
+	}
+	return args
+}
+
+// secretArgPrefixes are the prefixes of the arguments whose value is a secret, like the bundletool keystore passwords.
+var secretArgPrefixes = []string{"--ks-pass=pass:", "--key-pass=pass:"}
+
+// printableCommandArgs is command.PrintableCommandArgs, but the values of the secret arguments and
+// of every --dart-define argument are redacted.
+func printableCommandArgs(args []string) string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, dartDefineFlag+"="):
+			arg = redactDartDefine(dartDefineFlag+"=", strings.TrimPrefix(arg, dartDefineFlag+"="))
+		case i > 0 && args[i-1] == dartDefineFlag:
+			arg = redactDartDefine("", arg)
+		default:
+			for _, prefix := range secretArgPrefixes {
+				if strings.HasPrefix(arg, prefix) {
+					arg = prefix + redactedValue
+					break
+				}
+			}
+		}
+		redacted[i] = arg
+	}
+	return command.PrintableCommandArgs(false, redacted)
+}
+
+func redactDartDefine(prefix, define string) string {
+	key, _, found := strings.Cut(define, "=")
+	if !found {
+		return prefix + define
+	}
+	return prefix + key + "=" + redactedValue
+}
@@ -0,0 +1,89 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDartDefines(t *testing.T) {
+	t.Setenv("API_KEY", "secret-key")
+
+	tests := []struct {
+		name    string
+		content string
+		want    []dartDefine
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    nil,
+		},
+		{
+			name:    "key value pairs and environment variable reference",
+			content: "FLAVOR=dev\n\nAPI_KEY\nQUERY=a=b",
+			want: []dartDefine{
+				{key: "FLAVOR", value: "dev"},
+				{key: "API_KEY", value: "secret-key"},
+				{key: "QUERY", value: "a=b"},
+			},
+		},
+		{
+			name:    "missing environment variable",
+			content: "NOT_SET_DART_DEFINE_ENV",
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			content: "=value",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDartDefines(tt.content)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_readDartDefineFileValues(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPth := filepath.Join(dir, "defines.json")
+	require.NoError(t, os.WriteFile(jsonPth, []byte(`{"API_KEY": "secret-key"}`), 0644))
+	values, err := readDartDefineFileValues(jsonPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret-key"}, values)
+
+	envPth := filepath.Join(dir, "defines.env")
+	require.NoError(t, os.WriteFile(envPth, []byte("# comment\nAPI_KEY=\"secret-key\"\nFLAVOR=dev\n"), 0644))
+	values, err = readDartDefineFileValues(envPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret-key", "dev"}, values)
+}
+
+func Test_printableCommandArgs(t *testing.T) {
+	args := []string{
+		"flutter", "build", "apk",
+		"--dart-define=API_KEY=secret-key",
+		"--dart-define", "TOKEN=token",
+		"--dart-define-from-file=/tmp/defines.json",
+		"--obfuscate", "--split-debug-info=/secret-key",
+	}
+
+	got := printableCommandArgs(args)
+	require.Equal(t, `flutter "build" "apk" "--dart-define=API_KEY=[REDACTED]" "--dart-define" "TOKEN=[REDACTED]" "--dart-define-from-file=/tmp/defines.json" "--obfuscate" "--split-debug-info=/secret-key"`, got)
+
+	args = []string{"java", "-jar", "bundletool.jar", "build-apks", "--ks=release.jks", "--ks-pass=pass:storepass", "--ks-key-alias=key0", "--key-pass=pass:keypass"}
+	got = printableCommandArgs(args)
+	require.Equal(t, `java "-jar" "bundletool.jar" "build-apks" "--ks=release.jks" "--ks-pass=pass:[REDACTED]" "--ks-key-alias=key0" "--key-pass=pass:[REDACTED]"`, got)
+}
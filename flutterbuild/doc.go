@@ -0,0 +1,18 @@
+// Package flutterbuild builds Flutter apps for iOS and Android and exports the artifacts,
+// it implements the Flutter Build Step.
+//
+// A FlutterBuilder runs in three phases:
+//
+//	builder := flutterbuild.NewFlutterBuilder(flutterbuild.DefaultDependencies(), homeDir, deployDir)
+//	buildConfig, err := builder.ProcessConfig(inputs)
+//	out, err := builder.Run(buildConfig)
+//	err = builder.Export(buildConfig, out)
+//
+// ProcessConfig validates the Inputs, Run builds the selected platforms and Export exports the app metadata,
+// the artifacts and the dependency cache paths. The phases return a *StepError.
+//
+// Optional Inputs fields left at their zero value are disabled: no build name or number is set,
+// the project is not cleaned and the build is attempted once.
+//
+// Dependencies replaces the external commands and the envman output exporting, for example to capture the outputs.
+package flutterbuild
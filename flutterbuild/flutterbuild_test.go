@@ -0,0 +1,285 @@
+package flutterbuild
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFlutterRunner is a CommandRunner emulating flutter: `flutter build` writes fixture artifacts into the project.
+type fakeFlutterRunner struct {
+	t        *testing.T
+	commands []string
+	// shallow makes the git repository a shallow clone
+	shallow bool
+	// executables are the executables found by LookPath
+	executables []string
+}
+
+func (runner *fakeFlutterRunner) Run(cmd Command) error {
+	runner.commands = append(runner.commands, strings.Join(append([]string{cmd.Name}, cmd.Args...), " "))
+	if cmd.Name != "flutter" || len(cmd.Args) < 2 || cmd.Args[0] != "build" {
+		return nil
+	}
+
+	switch cmd.Args[1] {
+	case "apk":
+		apkDir := filepath.Join(cmd.Dir, "build", "app", "outputs", "apk", "release")
+		require.NoError(runner.t, os.MkdirAll(apkDir, 0755))
+		createTestZip(runner.t, filepath.Join(apkDir, "app-release.apk"), map[string][]byte{
+			"AndroidManifest.xml":     binaryXMLManifest(),
+			"lib/arm64-v8a/libapp.so": {},
+		})
+	case "ios":
+		createTestIOSApp(runner.t, filepath.Join(cmd.Dir, "build", "ios", "iphoneos", "Runner.app"), "io.bitrise.sample", time.Now())
+	case "ipa":
+		archive := filepath.Join(cmd.Dir, "build", "ios", "archive", "Runner.xcarchive")
+		createTestIOSApp(runner.t, filepath.Join(archive, "Products", "Applications", "Runner.app"), "io.bitrise.sample", time.Now())
+		require.NoError(runner.t, os.WriteFile(filepath.Join(archive, "Info.plist"), []byte(fmt.Sprintf(infoPlistTemplate, "")), 0644))
+	default:
+		return fmt.Errorf("unexpected build target: %s", cmd.Args[1])
+	}
+
+	_, err := fmt.Fprintf(cmd.Stdout, "✓ Built %s\n", cmd.Args[1])
+	return err
+}
+
+func (runner *fakeFlutterRunner) Output(dir, name string, args ...string) (string, error) {
+	runner.commands = append(runner.commands, strings.Join(append([]string{name}, args...), " "))
+	if name == "git" && len(args) > 0 && args[0] == "rev-list" {
+		return "41", nil
+	}
+	if name == "git" && len(args) > 1 && args[0] == "rev-parse" && args[1] == "--is-shallow-repository" {
+		return strconv.FormatBool(runner.shallow), nil
+	}
+	return "", fmt.Errorf("unexpected command: %s", name)
+}
+
+func (runner *fakeFlutterRunner) LookPath(name string) (string, error) {
+	if sliceutil.IsStringInSlice(name, runner.executables) {
+		return filepath.Join("/usr/local/bin", name), nil
+	}
+	return "", fmt.Errorf("executable file not found in $PATH: %s", name)
+}
+
+// fakeExporter records the exported outputs instead of calling envman.
+type fakeExporter struct {
+	envs map[string]string
+}
+
+func (exporter *fakeExporter) ExportEnv(key, value string) error {
+	exporter.envs[key] = value
+	return nil
+}
+
+func (exporter *fakeExporter) ExportFile(sourcePath, destinationPath, key string) error {
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(destinationPath, content, 0644); err != nil {
+		return err
+	}
+	exporter.envs[key] = destinationPath
+	return nil
+}
+
+func createTestProject(t *testing.T) string {
+	projectDir := t.TempDir()
+	pubCacheDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pubspec.yaml"), []byte(`name: sample
+description: Sample app
+version: 1.2.3+4
+environment:
+  sdk: ">=3.0.0 <4.0.0"
+`), 0644))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "ios"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "ios", "Podfile.lock"), []byte("PODFILE CHECKSUM: 1"), 0644))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, ".dart_tool"), 0755))
+	packageConfig := fmt.Sprintf(`{"packages": [{"name": "http", "rootUri": "file://%s/.pub-cache/hosted/pub.dev/http-1.2.0", "packageUri": "lib/"}]}`, pubCacheDir)
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".dart_tool", "package_config.json"), []byte(packageConfig), 0644))
+
+	return projectDir
+}
+
+func testConfig(projectDir string) Inputs {
+	return Inputs{
+		ProjectLocation:   projectDir,
+		Platform:          "both",
+		CacheLevel:        "all",
+		CleanMode:         CleanModeNone,
+		BuildNumberSource: BuildNumberSourceGitCommitCount,
+		BuildNumberOffset: 1,
+		BuildNameSource:   BuildNameSourcePubspec,
+		BuildMaxAttempts:  1,
+		IOSOutputType:     OutputTypeArchive,
+		IOSExportPattern:  []string{"*build/ios/iphoneos/*.app", "*build/ios/archive/*.xcarchive"},
+		AndroidOutputType: OutputTypeAPK,
+		AndroidExportPattern: []string{
+			"*build/app/outputs/apk/*/*.apk",
+			"*build/app/outputs/bundle/*/*.aab",
+		},
+		AndroidVerifyVersion: false,
+	}
+}
+
+// runPhases runs the phases of the builder the way the Step does.
+func runPhases(builder FlutterBuilder, inputs Inputs) error {
+	buildConfig, err := builder.ProcessConfig(inputs)
+	if err != nil {
+		return err
+	}
+
+	out, runErr := builder.Run(buildConfig)
+	exportErr := builder.Export(buildConfig, out)
+	if runErr != nil {
+		return runErr
+	}
+	return exportErr
+}
+
+func TestFlutterBuilder(t *testing.T) {
+	deployDir := t.TempDir()
+	t.Setenv("BITRISE_CACHE_INCLUDE_PATHS", "")
+	t.Setenv("BITRISE_CACHE_EXCLUDE_PATHS", "")
+
+	projectDir := createTestProject(t)
+	runner := &fakeFlutterRunner{t: t}
+	exporter := &fakeExporter{envs: map[string]string{}}
+	deps := Dependencies{
+		Runner:   runner,
+		Exporter: exporter,
+		InstalledCodesignIdentities: func() ([]string, error) {
+			return []string{"iPhone Distribution: Bitrise Sample (ABCDE12345)"}, nil
+		},
+	}
+
+	builder := NewFlutterBuilder(deps, t.TempDir(), deployDir)
+	require.NoError(t, runPhases(builder, testConfig(projectDir)))
+
+	require.Equal(t, []string{
+		"git rev-parse --is-shallow-repository",
+		"git rev-list --count HEAD",
+		"flutter build ipa --build-name=1.2.3 --build-number=42",
+		"flutter build apk --build-name=1.2.3 --build-number=42",
+	}, runner.commands)
+
+	require.Equal(t, "sample", exporter.envs["FLUTTER_APP_NAME"])
+	require.Equal(t, "42", exporter.envs["FLUTTER_BUILD_NUMBER"])
+
+	require.Equal(t, filepath.Join(deployDir, "app-release.apk"), exporter.envs["BITRISE_APK_PATH"])
+	require.FileExists(t, exporter.envs["BITRISE_APK_PATH"])
+	require.Equal(t, "io.bitrise.sample", exporter.envs["FLUTTER_ANDROID_APPLICATION_ID"])
+
+	require.Equal(t, filepath.Join(deployDir, "Runner.xcarchive.zip"), exporter.envs["BITRISE_XCARCHIVE_ZIP_PATH"])
+	require.FileExists(t, exporter.envs["BITRISE_XCARCHIVE_ZIP_PATH"])
+	require.Equal(t, "io.bitrise.sample", exporter.envs["FLUTTER_IOS_BUNDLE_ID"])
+
+	cachePaths := exporter.envs["BITRISE_CACHE_INCLUDE_PATHS"]
+	require.Contains(t, cachePaths, filepath.Join(projectDir, "ios", "Pods")+" -> "+filepath.Join(projectDir, "ios", "Podfile.lock"))
+	require.Contains(t, cachePaths, ".pub-cache")
+}
+
+func TestFlutterBuilder_noCodesignIdentity(t *testing.T) {
+	runner := &fakeFlutterRunner{t: t}
+	deps := Dependencies{
+		Runner:   runner,
+		Exporter: &fakeExporter{envs: map[string]string{}},
+		InstalledCodesignIdentities: func() ([]string, error) {
+			return nil, nil
+		},
+	}
+
+	cfg := testConfig(createTestProject(t))
+	cfg.BuildNumberSource = BuildNumberSourceNone
+	err := runPhases(NewFlutterBuilder(deps, t.TempDir(), t.TempDir()), cfg)
+	require.EqualError(t, err, "Run: no codesign identities installed")
+
+	var stepErr *StepError
+	require.True(t, errors.As(err, &stepErr))
+	require.Equal(t, PhaseRun, stepErr.Phase)
+	require.Empty(t, runner.commands)
+}
+
+func TestFlutterBuilder_continueOnError(t *testing.T) {
+	t.Setenv("BITRISE_CACHE_INCLUDE_PATHS", "")
+	t.Setenv("BITRISE_CACHE_EXCLUDE_PATHS", "")
+
+	t.Run("failed build", func(t *testing.T) {
+		runner := &fakeFlutterRunner{t: t}
+		exporter := &fakeExporter{envs: map[string]string{}}
+		builder := NewFlutterBuilder(Dependencies{Runner: runner, Exporter: exporter}, t.TempDir(), t.TempDir())
+
+		cfg := testConfig(createTestProject(t))
+		cfg.BuildNumberSource = BuildNumberSourceNone
+		cfg.IOSOutputType = OutputTypeIOSApp
+		cfg.AndroidOutputType = "web"
+		cfg.ContinueOnError = true
+
+		err := runPhases(builder, cfg)
+		require.EqualError(t, err, "Run: Android app build failed: unexpected build target: web")
+		require.Equal(t, []string{
+			"flutter build ios --build-name=1.2.3 --no-codesign",
+			"flutter build web --build-name=1.2.3",
+		}, runner.commands)
+		require.DirExists(t, exporter.envs["BITRISE_APP_DIR_PATH"])
+	})
+
+	t.Run("failed codesign preparation", func(t *testing.T) {
+		runner := &fakeFlutterRunner{t: t}
+		exporter := &fakeExporter{envs: map[string]string{}}
+		deps := Dependencies{
+			Runner:   runner,
+			Exporter: exporter,
+			InstalledCodesignIdentities: func() ([]string, error) {
+				return nil, nil
+			},
+		}
+		builder := NewFlutterBuilder(deps, t.TempDir(), t.TempDir())
+
+		cfg := testConfig(createTestProject(t))
+		cfg.BuildNumberSource = BuildNumberSourceNone
+		cfg.ContinueOnError = true
+
+		err := runPhases(builder, cfg)
+		require.EqualError(t, err, "Run: iOS app build failed: no codesign identities installed")
+
+		var stepErr *StepError
+		require.True(t, errors.As(err, &stepErr))
+		require.Equal(t, PhaseRun, stepErr.Phase)
+		require.Equal(t, []string{"flutter build apk --build-name=1.2.3"}, runner.commands)
+		require.FileExists(t, exporter.envs["BITRISE_APK_PATH"])
+	})
+
+	t.Run("failed export", func(t *testing.T) {
+		runner := &fakeFlutterRunner{t: t}
+		exporter := &fakeExporter{envs: map[string]string{}}
+		builder := NewFlutterBuilder(Dependencies{Runner: runner, Exporter: exporter}, t.TempDir(), t.TempDir())
+
+		cfg := testConfig(createTestProject(t))
+		cfg.BuildNumberSource = BuildNumberSourceNone
+		cfg.IOSOutputType = OutputTypeIOSApp
+		cfg.AndroidExportPattern = []string{"*build/app/outputs/bundle/*/*.aab"}
+		cfg.ContinueOnError = true
+
+		err := runPhases(builder, cfg)
+		require.Error(t, err)
+
+		var stepErr *StepError
+		require.True(t, errors.As(err, &stepErr))
+		require.Equal(t, PhaseExport, stepErr.Phase)
+		require.Contains(t, err.Error(), "Android app export failed")
+		require.DirExists(t, exporter.envs["BITRISE_APP_DIR_PATH"])
+	})
+}
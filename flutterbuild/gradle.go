@@ -0,0 +1,122 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GradleSwitch turns a Gradle feature on or off, or keeps the project's setting.
+type GradleSwitch string
+
+// GradleSwitch values
+const (
+	GradleSwitchDefault  GradleSwitch = "default"
+	GradleSwitchEnabled  GradleSwitch = "enabled"
+	GradleSwitchDisabled GradleSwitch = "disabled"
+)
+
+const (
+	gradleOptsEnv             = "GRADLE_OPTS"
+	gradleProjectPropertyEnv  = "ORG_GRADLE_PROJECT_"
+	gradleJVMArgsProperty     = "org.gradle.jvmargs"
+	gradleDaemonProperty      = "org.gradle.daemon"
+	gradleParallelProperty    = "org.gradle.parallel"
+	gradleConfigCacheProperty = "org.gradle.configuration-cache"
+)
+
+var (
+	gradleJVMMemoryRegexp    = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+	gradlePropertyNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// gradleSettings tune the Gradle build of the Android app without editing the project's gradle.properties.
+type gradleSettings struct {
+	// jvmMaxHeap is the -Xmx of the Gradle daemon, for example 4g.
+	// It is set as the whole org.gradle.jvmargs, overriding the other JVM arguments of the project's gradle.properties.
+	jvmMaxHeap         string
+	daemon             GradleSwitch
+	parallel           GradleSwitch
+	configurationCache GradleSwitch
+	// projectProperties are the -P project properties
+	projectProperties []gradleProperty
+}
+
+type gradleProperty struct {
+	name  string
+	value string
+}
+
+// parseGradleProjectProperties parses the Gradle project properties input: a `NAME=VALUE` pair per line.
+func parseGradleProjectProperties(lines []string) ([]gradleProperty, error) {
+	var properties []gradleProperty
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found || !gradlePropertyNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid Gradle project property, expected NAME=VALUE: %s", line)
+		}
+		properties = append(properties, gradleProperty{name: name, value: strings.TrimSpace(value)})
+	}
+	return properties, nil
+}
+
+func (settings gradleSettings) validate() error {
+	if settings.jvmMaxHeap != "" && !gradleJVMMemoryRegexp.MatchString(settings.jvmMaxHeap) {
+		return fmt.Errorf("invalid Gradle JVM max heap size (%s), use a size like 4g or 4096m", settings.jvmMaxHeap)
+	}
+	return nil
+}
+
+// systemProperties returns the Gradle build environment properties set by the settings, as -D JVM options.
+func (settings gradleSettings) systemProperties() []string {
+	var properties []string
+	if settings.jvmMaxHeap != "" {
+		properties = append(properties, fmt.Sprintf("-D%s=-Xmx%s", gradleJVMArgsProperty, settings.jvmMaxHeap))
+	}
+
+	switches := []struct {
+		property string
+		value    GradleSwitch
+	}{
+		{gradleDaemonProperty, settings.daemon},
+		{gradleParallelProperty, settings.parallel},
+		{gradleConfigCacheProperty, settings.configurationCache},
+	}
+	for _, s := range switches {
+		switch s.value {
+		case GradleSwitchEnabled:
+			properties = append(properties, fmt.Sprintf("-D%s=true", s.property))
+		case GradleSwitchDisabled:
+			properties = append(properties, fmt.Sprintf("-D%s=false", s.property))
+		}
+	}
+	return properties
+}
+
+// printableGradleEnv returns env with the value of a Gradle project property redacted, as they often hold credentials.
+func printableGradleEnv(env string) string {
+	if name, _, found := strings.Cut(env, "="); found && strings.HasPrefix(name, gradleProjectPropertyEnv) {
+		return name + "=" + redactedValue
+	}
+	return env
+}
+
+// env returns the environment variables applying the settings to the Gradle build started by flutter.
+// The options are appended to the GRADLE_OPTS inherited from the Step's environment (gradleOpts).
+func (settings gradleSettings) env(gradleOpts string) []string {
+	var envs []string
+	if properties := settings.systemProperties(); len(properties) > 0 {
+		opts := strings.TrimSpace(strings.Join(append([]string{gradleOpts}, properties...), " "))
+		envs = append(envs, gradleOptsEnv+"="+opts)
+	}
+	for _, property := range settings.projectProperties {
+		envs = append(envs, gradleProjectPropertyEnv+property.name+"="+property.value)
+	}
+	return envs
+}
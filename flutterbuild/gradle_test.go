@@ -0,0 +1,102 @@
+package flutterbuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseGradleProjectProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []gradleProperty
+		wantErr string
+	}{
+		{
+			name:  "Empty",
+			lines: []string{"", "  "},
+		},
+		{
+			name:  "Properties",
+			lines: []string{"android.injected.version.code=42", " versionSuffix = -ci ", "empty="},
+			want: []gradleProperty{
+				{name: "android.injected.version.code", value: "42"},
+				{name: "versionSuffix", value: "-ci"},
+				{name: "empty", value: ""},
+			},
+		},
+		{
+			name:    "Missing value",
+			lines:   []string{"versionSuffix"},
+			wantErr: "invalid Gradle project property, expected NAME=VALUE: versionSuffix",
+		},
+		{
+			name:    "Invalid name",
+			lines:   []string{"version suffix=-ci"},
+			wantErr: "invalid Gradle project property, expected NAME=VALUE: version suffix=-ci",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGradleProjectProperties(tt.lines)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_gradleSettings_validate(t *testing.T) {
+	require.NoError(t, gradleSettings{}.validate())
+	require.NoError(t, gradleSettings{jvmMaxHeap: "4g"}.validate())
+	require.NoError(t, gradleSettings{jvmMaxHeap: "4096m"}.validate())
+	require.EqualError(t, gradleSettings{jvmMaxHeap: "-Xmx4g"}.validate(), "invalid Gradle JVM max heap size (-Xmx4g), use a size like 4g or 4096m")
+}
+
+func Test_gradleSettings_env(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   gradleSettings
+		gradleOpts string
+		want       []string
+	}{
+		{
+			name:     "Project defaults",
+			settings: gradleSettings{daemon: GradleSwitchDefault, parallel: GradleSwitchDefault, configurationCache: GradleSwitchDefault},
+		},
+		{
+			name: "All settings",
+			settings: gradleSettings{
+				jvmMaxHeap:         "6g",
+				daemon:             GradleSwitchDisabled,
+				parallel:           GradleSwitchEnabled,
+				configurationCache: GradleSwitchEnabled,
+				projectProperties:  []gradleProperty{{name: "versionSuffix", value: "-ci"}},
+			},
+			want: []string{
+				"GRADLE_OPTS=-Dorg.gradle.jvmargs=-Xmx6g -Dorg.gradle.daemon=false -Dorg.gradle.parallel=true -Dorg.gradle.configuration-cache=true",
+				"ORG_GRADLE_PROJECT_versionSuffix=-ci",
+			},
+		},
+		{
+			name:       "Appends to the inherited GRADLE_OPTS",
+			settings:   gradleSettings{daemon: GradleSwitchDisabled},
+			gradleOpts: "-Dfile.encoding=UTF-8",
+			want:       []string{"GRADLE_OPTS=-Dfile.encoding=UTF-8 -Dorg.gradle.daemon=false"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.settings.env(tt.gradleOpts))
+		})
+	}
+}
+
+func Test_printableGradleEnv(t *testing.T) {
+	require.Equal(t, "GRADLE_OPTS=-Dorg.gradle.daemon=false", printableGradleEnv("GRADLE_OPTS=-Dorg.gradle.daemon=false"))
+	require.Equal(t, "ORG_GRADLE_PROJECT_storePassword=[REDACTED]", printableGradleEnv("ORG_GRADLE_PROJECT_storePassword=secret"))
+}
@@ -0,0 +1,99 @@
+package flutterbuild
+
+import (
+	"errors"
+
+	"github.com/bitrise-io/go-steputils/stepconf"
+)
+
+// OutputType is the artifact type built for a platform.
+type OutputType string
+
+const (
+	codesignField  = "ios-signing-cert"
+	noCodesignFlag = "--no-codesign"
+
+	OutputTypeAPK       OutputType = "apk"
+	OutputTypeAppBundle OutputType = "appbundle"
+
+	OutputTypeIOSApp  OutputType = "app"     // CLI: flutter build ios
+	OutputTypeArchive OutputType = "archive" // CLI: flutter build ipa
+)
+
+// ErrCodeSign is the build error of an iOS app requiring code signing.
+var ErrCodeSign = errors.New("CODESIGN")
+
+// Inputs are the Step inputs, the env tags are the Step input keys.
+type Inputs struct {
+	ProjectLocation       string `env:"project_location,dir"`
+	Platform              string `env:"platform,opt[both,ios,android]"`
+	AdditionalBuildParams string `env:"additional_build_params"`
+	DebugMode             bool   `env:"is_debug_mode,opt[true,false]"`
+	DryRun                bool   `env:"dry_run,opt[true,false]"`
+	CacheLevel            string `env:"cache_level,opt[all,none]"`
+
+	DartDefines        stepconf.Secret `env:"dart_defines"`
+	DartDefineFromFile string          `env:"dart_define_from_file"`
+
+	BuildNumberSource BuildNumberSource `env:"build_number_source,opt[none,explicit,bitrise_build_number,git_commit_count]"`
+	BuildNumber       string            `env:"build_number"`
+	BuildNumberOffset int               `env:"build_number_offset"`
+	BuildNameSource   BuildNameSource   `env:"build_name_source,opt[none,pubspec,git_tag]"`
+
+	CleanMode          CleanMode `env:"clean_mode,opt[none,flutter_clean,build_dir]"`
+	WorkspaceBootstrap bool      `env:"workspace_bootstrap,opt[true,false]"`
+	PreBuildPhases     []string  `env:"pre_build_phases,multiline"`
+
+	BuildInactivityTimeout int      `env:"build_inactivity_timeout,range[0..]"`
+	BuildMaxAttempts       int      `env:"build_max_attempts,range[1..]"`
+	BuildRetrySignatures   []string `env:"build_retry_signatures,multiline"`
+
+	ContinueOnError bool `env:"continue_on_error,opt[true,false]"`
+
+	ArtifactNameTemplate string `env:"artifact_name_template"`
+
+	ParallelBuilds bool           `env:"parallel_builds,opt[true,false]"`
+	ParallelOutput ParallelOutput `env:"parallel_output,opt[prefixed,buffered]"`
+	ParallelExport ParallelExport `env:"parallel_export,opt[all_succeeded,partial]"`
+
+	IOSOutputType       OutputType `env:"ios_output_type,opt[app,archive]"`
+	IOSAdditionalParams string     `env:"ios_additional_params"`
+	IOSExportPattern    []string   `env:"ios_output_pattern,multiline"`
+	IOSCodesignIdentity string     `env:"ios_codesign_identity"`
+	IOSBundleID         string     `env:"ios_bundle_id"`
+	IOSBuildTimeout     int        `env:"ios_build_timeout,range[0..]"`
+
+	AndroidOutputType       OutputType `env:"android_output_type,opt[apk,appbundle]"`
+	AndroidAdditionalParams string     `env:"android_additional_params"`
+	AndroidExportPattern    []string   `env:"android_output_pattern,multiline"`
+	AndroidBuildTimeout     int        `env:"android_build_timeout,range[0..]"`
+
+	GradleJVMMaxHeap         string       `env:"android_gradle_jvm_max_heap"`
+	GradleDaemon             GradleSwitch `env:"android_gradle_daemon,opt[default,enabled,disabled]"`
+	GradleParallel           GradleSwitch `env:"android_gradle_parallel,opt[default,enabled,disabled]"`
+	GradleConfigurationCache GradleSwitch `env:"android_gradle_configuration_cache,opt[default,enabled,disabled]"`
+	GradleProjectProperties  []string     `env:"android_gradle_project_properties,multiline"`
+
+	AndroidExpectedApplicationID string `env:"android_expected_application_id"`
+	AndroidVerifyVersion         bool   `env:"android_verify_version,opt[true,false]"`
+
+	AndroidSplitPerABI bool   `env:"android_split_per_abi,opt[true,false]"`
+	AndroidPrimaryABI  string `env:"android_primary_abi,opt[arm64-v8a,armeabi-v7a,x86_64]"`
+
+	SizeAnalysis                      bool   `env:"size_analysis,opt[true,false]"`
+	SizeAnalysisAndroidTargetPlatform string `env:"size_analysis_android_target_platform,opt[android-arm64,android-arm,android-x64]"`
+	SizeBudgetArtifact                string `env:"size_budget_artifact"`
+	SizeBudgetDartAOT                 string `env:"size_budget_dart_aot"`
+	SizeBaselinePath                  string `env:"size_baseline_path"`
+	SizeGrowthThreshold               string `env:"size_growth_threshold"`
+
+	GenerateUniversalAPK bool            `env:"generate_universal_apk,opt[true,false]"`
+	BundletoolPath       string          `env:"bundletool_path"`
+	KeystoreURL          stepconf.Secret `env:"keystore_url"`
+	KeystorePassword     stepconf.Secret `env:"keystore_password"`
+	KeystoreAlias        string          `env:"keystore_alias"`
+	PrivateKeyPassword   stepconf.Secret `env:"private_key_password"`
+
+	// Deprecated
+	AndroidBundleExportPattern []string `env:"android_bundle_output_pattern,multiline"`
+}
@@ -0,0 +1,172 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-xcode/plistutil"
+	"github.com/bitrise-io/go-xcode/profileutil"
+)
+
+// iosAppPath returns the path of the application bundle of an iOS artifact:
+// the .app itself or the application in the Products/Applications directory of an .xcarchive.
+func iosAppPath(artifact string) (string, error) {
+	if filepath.Ext(artifact) != ".xcarchive" {
+		return artifact, nil
+	}
+
+	pattern := filepath.Join(artifact, "Products", "Applications", "*.app")
+	apps, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", err
+	}
+	if len(apps) == 0 {
+		return "", fmt.Errorf("no application found in %s", filepath.Dir(pattern))
+	}
+	return apps[0], nil
+}
+
+// iosAppInfoPlist reads the (binary or XML) Info.plist of an iOS artifact's application bundle.
+func iosAppInfoPlist(artifact string) (plistutil.PlistData, error) {
+	appPath, err := iosAppPath(artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	infoPlistPth := filepath.Join(appPath, "Info.plist")
+	infoPlist, err := plistutil.NewPlistDataFromFile(infoPlistPth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", infoPlistPth, err)
+	}
+	return infoPlist, nil
+}
+
+// selectIOSArtifact selects the artifact to export from multiple matching .app or .xcarchive bundles.
+// If bundleID is set only the artifacts with a matching CFBundleIdentifier are considered,
+// from the candidates the most recently modified one is selected.
+func selectIOSArtifact(artifacts []string, bundleID string) (string, error) {
+	candidates := artifacts
+	if bundleID != "" {
+		candidates = nil
+		var foundBundleIDs []string
+		for _, artifact := range artifacts {
+			infoPlist, err := iosAppInfoPlist(artifact)
+			if err != nil {
+				log.Warnf("Failed to read the bundle identifier of %s: %s", artifact, err)
+				continue
+			}
+
+			artifactBundleID, _ := infoPlist.GetString("CFBundleIdentifier")
+			if artifactBundleID == bundleID {
+				candidates = append(candidates, artifact)
+			} else {
+				log.Debugf("Artifact (%s) bundle identifier (%s) does not match %s - Skip", artifact, artifactBundleID, bundleID)
+				foundBundleIDs = append(foundBundleIDs, artifactBundleID)
+			}
+		}
+
+		if len(candidates) == 0 {
+			return "", fmt.Errorf("none of the artifacts (%s) has the bundle identifier %s, found bundle identifiers: %s", strings.Join(artifacts, ", "), bundleID, strings.Join(foundBundleIDs, ", "))
+		}
+	}
+
+	modTimes := map[string]time.Time{}
+	for _, artifact := range candidates {
+		info, err := os.Stat(artifact)
+		if err != nil {
+			return "", err
+		}
+		modTimes[artifact] = artifactModTime(artifact, info)
+	}
+
+	sorted := append([]string{}, candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return modTimes[sorted[i]].After(modTimes[sorted[j]])
+	})
+
+	return sorted[0], nil
+}
+
+// iosArtifactInfo is the metadata read from the Info.plist and the embedded provisioning profile of an iOS artifact.
+type iosArtifactInfo struct {
+	bundleID         string
+	versionName      string
+	buildNumber      string
+	minimumOSVersion string
+
+	// Empty if the app is not signed (e.g. built with --no-codesign)
+	profileName   string
+	profileTeamID string
+	profileExpiry time.Time
+}
+
+func inspectIOSArtifact(artifact string) (iosArtifactInfo, error) {
+	infoPlist, err := iosAppInfoPlist(artifact)
+	if err != nil {
+		return iosArtifactInfo{}, err
+	}
+
+	var info iosArtifactInfo
+	info.bundleID, _ = infoPlist.GetString("CFBundleIdentifier")
+	info.versionName, _ = infoPlist.GetString("CFBundleShortVersionString")
+	info.buildNumber, _ = infoPlist.GetString("CFBundleVersion")
+	info.minimumOSVersion, _ = infoPlist.GetString("MinimumOSVersion")
+
+	appPath, err := iosAppPath(artifact)
+	if err != nil {
+		return iosArtifactInfo{}, err
+	}
+
+	profilePth := filepath.Join(appPath, "embedded.mobileprovision")
+	if exist, err := pathutil.IsPathExists(profilePth); err != nil {
+		return iosArtifactInfo{}, err
+	} else if !exist {
+		return info, nil
+	}
+
+	profile, err := profileutil.NewPlistDataFromFile(profilePth)
+	if err != nil {
+		return iosArtifactInfo{}, fmt.Errorf("failed to read the embedded provisioning profile (%s): %s", profilePth, err)
+	}
+	info.profileName = profile.GetName()
+	info.profileTeamID = profile.GetTeamID()
+	info.profileExpiry = profile.GetExpirationDate()
+
+	return info, nil
+}
+
+func (info iosArtifactInfo) print() {
+	log.Printf("  Bundle ID: %s", info.bundleID)
+	log.Printf("  Version: %s", info.versionName)
+	log.Printf("  Build number: %s", info.buildNumber)
+	log.Printf("  Minimum OS version: %s", info.minimumOSVersion)
+	if info.profileName == "" {
+		log.Printf("  Provisioning profile: not embedded")
+		return
+	}
+	log.Printf("  Provisioning profile: %s", info.profileName)
+	log.Printf("  Team ID: %s", info.profileTeamID)
+	log.Printf("  Profile expiry: %s", info.profileExpiry.Format(time.RFC3339))
+}
+
+func exportIOSArtifactInfo(exporter OutputExporter, info iosArtifactInfo) error {
+	profileExpiry := ""
+	if !info.profileExpiry.IsZero() {
+		profileExpiry = info.profileExpiry.Format(time.RFC3339)
+	}
+
+	return exportOutputs(exporter, []envOutput{
+		{"FLUTTER_IOS_BUNDLE_ID", info.bundleID},
+		{"FLUTTER_IOS_VERSION_NAME", info.versionName},
+		{"FLUTTER_IOS_BUILD_NUMBER", info.buildNumber},
+		{"FLUTTER_IOS_MINIMUM_OS_VERSION", info.minimumOSVersion},
+		{"FLUTTER_IOS_PROFILE_TEAM_ID", info.profileTeamID},
+		{"FLUTTER_IOS_PROFILE_EXPIRY", profileExpiry},
+	})
+}
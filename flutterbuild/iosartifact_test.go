@@ -0,0 +1,107 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"howett.net/plist"
+)
+
+const infoPlistTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>%s</string>
+</dict>
+</plist>`
+
+func createTestIOSApp(t *testing.T, pth, bundleID string, modTime time.Time) {
+	require.NoError(t, os.MkdirAll(pth, 0755))
+	infoPlistPth := filepath.Join(pth, "Info.plist")
+	require.NoError(t, os.WriteFile(infoPlistPth, []byte(fmt.Sprintf(infoPlistTemplate, bundleID)), 0644))
+	require.NoError(t, os.Chtimes(infoPlistPth, modTime, modTime))
+	require.NoError(t, os.Chtimes(pth, modTime, modTime))
+}
+
+func Test_selectIOSArtifact(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	devApp := filepath.Join(dir, "dev", "Runner.app")
+	createTestIOSApp(t, devApp, "io.bitrise.app.dev", now)
+	prodApp := filepath.Join(dir, "prod", "Runner.app")
+	createTestIOSApp(t, prodApp, "io.bitrise.app", now.Add(-time.Hour))
+	prodArchive := filepath.Join(dir, "Runner.xcarchive")
+	createTestIOSApp(t, filepath.Join(prodArchive, "Products", "Applications", "Runner.app"), "io.bitrise.app", now.Add(-time.Minute))
+
+	tests := []struct {
+		name      string
+		artifacts []string
+		bundleID  string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "most recent artifact",
+			artifacts: []string{prodApp, devApp},
+			want:      devApp,
+		},
+		{
+			name:      "matching bundle identifier",
+			artifacts: []string{devApp, prodApp},
+			bundleID:  "io.bitrise.app",
+			want:      prodApp,
+		},
+		{
+			name:      "matching bundle identifier in xcarchive",
+			artifacts: []string{prodArchive},
+			bundleID:  "io.bitrise.app",
+			want:      prodArchive,
+		},
+		{
+			name:      "no matching bundle identifier",
+			artifacts: []string{devApp, prodApp},
+			bundleID:  "io.bitrise.other",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectIOSArtifact(tt.artifacts, tt.bundleID)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_inspectIOSArtifact(t *testing.T) {
+	appPth := filepath.Join(t.TempDir(), "Runner.xcarchive", "Products", "Applications", "Runner.app")
+	require.NoError(t, os.MkdirAll(appPth, 0755))
+
+	infoPlist, err := plist.Marshal(map[string]string{
+		"CFBundleIdentifier":         "io.bitrise.app",
+		"CFBundleShortVersionString": "1.2.3",
+		"CFBundleVersion":            "4",
+		"MinimumOSVersion":           "12.0",
+	}, plist.BinaryFormat)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(appPth, "Info.plist"), infoPlist, 0644))
+
+	got, err := inspectIOSArtifact(filepath.Dir(filepath.Dir(filepath.Dir(appPth))))
+	require.NoError(t, err)
+	require.Equal(t, iosArtifactInfo{
+		bundleID:         "io.bitrise.app",
+		versionName:      "1.2.3",
+		buildNumber:      "4",
+		minimumOSVersion: "12.0",
+	}, got)
+}
@@ -0,0 +1,133 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// prunedDirNames are not walked when looking for artifacts, unless an output pattern explicitly refers to them.
+var prunedDirNames = []string{".git", "node_modules", "Pods", ".dart_tool", ".symlinks", ".gradle"}
+
+// outputPattern matches artifact paths under the project location.
+//
+// Patterns containing `**` or not starting with `*` are doublestar globs relative to the project location
+// (or absolute), where `*` does not match across path separators, e.g. `build/app/outputs/**/*.apk`.
+//
+// For backwards compatibility other patterns (like the default `*build/app/outputs/apk/*/*.apk`) are matched
+// against the absolute path, where `*` matches any sequence of characters including path separators.
+type outputPattern struct {
+	pattern         string
+	projectLocation string
+
+	legacy       bool
+	legacyRegexp *regexp.Regexp
+	legacyPrefix string
+
+	segments []string
+}
+
+func isLegacyOutputPattern(pattern string) bool {
+	if strings.Contains(pattern, "**") {
+		return false
+	}
+	return strings.HasPrefix(pattern, "*") || filepath.IsAbs(pattern)
+}
+
+func newOutputPattern(projectLocation, pattern string) (outputPattern, error) {
+	p := outputPattern{
+		pattern:         pattern,
+		projectLocation: projectLocation,
+	}
+
+	if isLegacyOutputPattern(pattern) {
+		parts := strings.Split(pattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+
+		p.legacy = true
+		p.legacyRegexp = regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+		p.legacyPrefix = pattern
+		if i := strings.Index(pattern, "*"); i != -1 {
+			p.legacyPrefix = pattern[:i]
+		}
+		return p, nil
+	}
+
+	slashPattern := filepath.ToSlash(pattern)
+	if !doublestar.ValidatePattern(slashPattern) {
+		return outputPattern{}, fmt.Errorf("invalid output pattern: %s", pattern)
+	}
+	p.pattern = slashPattern
+	p.segments = strings.Split(strings.TrimPrefix(slashPattern, "/"), "/")
+	return p, nil
+}
+
+// relativePath returns the path to match the doublestar pattern against.
+func (p outputPattern) relativePath(pth string) string {
+	if filepath.IsAbs(p.pattern) {
+		return filepath.ToSlash(pth)
+	}
+
+	rel, err := filepath.Rel(p.projectLocation, pth)
+	if err != nil {
+		return filepath.ToSlash(pth)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (p outputPattern) match(pth string) bool {
+	if p.legacy {
+		return p.legacyRegexp.MatchString(pth)
+	}
+	return doublestar.MatchUnvalidated(p.pattern, p.relativePath(pth))
+}
+
+// mayMatchIn reports whether the pattern may match any path in the given directory,
+// so that the walk can be pruned at directories which cannot contain artifacts.
+func (p outputPattern) mayMatchIn(dir string) bool {
+	name := filepath.Base(dir)
+	for _, pruned := range prunedDirNames {
+		if name == pruned && !strings.Contains(p.pattern, pruned) {
+			return false
+		}
+	}
+
+	if p.legacy {
+		dir += string(filepath.Separator)
+		return strings.HasPrefix(dir, p.legacyPrefix) || strings.HasPrefix(p.legacyPrefix, dir)
+	}
+
+	relDir := p.relativePath(dir)
+	if relDir == "." {
+		return true
+	}
+	return segmentsMayMatch(p.segments, strings.Split(strings.TrimPrefix(relDir, "/"), "/"))
+}
+
+func segmentsMayMatch(patternSegments, dirSegments []string) bool {
+	for i, dirSegment := range dirSegments {
+		if i >= len(patternSegments) {
+			return false
+		}
+
+		patternSegment := patternSegments[i]
+		if patternSegment == "**" {
+			return true
+		}
+
+		match, err := doublestar.Match(patternSegment, dirSegment)
+		if err != nil {
+			// e.g. a brace expression containing a path separator, do not prune
+			return true
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
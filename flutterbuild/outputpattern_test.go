@@ -0,0 +1,141 @@
+package flutterbuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_outputPattern_match(t *testing.T) {
+	const projectLocation = "/bitrise/src"
+
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{
+			name:    "legacy pattern",
+			pattern: "*build/app/outputs/apk/*/*.apk",
+			path:    "/bitrise/src/build/app/outputs/apk/release/app-release.apk",
+			want:    true,
+		},
+		{
+			name:    "legacy pattern star matches path separators",
+			pattern: "*build/app/outputs/apk/*/*.apk",
+			path:    "/bitrise/src/build/app/outputs/apk/dev/release/app-dev-release.apk",
+			want:    true,
+		},
+		{
+			name:    "absolute legacy pattern",
+			pattern: "/bitrise/src/build/ios/iphoneos/*.app",
+			path:    "/bitrise/src/build/ios/iphoneos/Runner.app",
+			want:    true,
+		},
+		{
+			name:    "relative pattern",
+			pattern: "build/app/outputs/bundle/*/*.aab",
+			path:    "/bitrise/src/build/app/outputs/bundle/release/app-release.aab",
+			want:    true,
+		},
+		{
+			name:    "relative pattern star does not match path separators",
+			pattern: "build/app/outputs/apk/*/*.apk",
+			path:    "/bitrise/src/build/app/outputs/apk/dev/release/app-dev-release.apk",
+			want:    false,
+		},
+		{
+			name:    "doublestar pattern",
+			pattern: "**/outputs/apk/**/*.apk",
+			path:    "/bitrise/src/build/app/outputs/apk/dev/release/app-dev-release.apk",
+			want:    true,
+		},
+		{
+			name:    "absolute doublestar pattern",
+			pattern: "/bitrise/src/build/**/*.{apk,aab}",
+			path:    "/bitrise/src/build/app/outputs/bundle/release/app-release.aab",
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := newOutputPattern(projectLocation, tt.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, pattern.match(tt.path))
+		})
+	}
+}
+
+func Test_outputPattern_mayMatchIn(t *testing.T) {
+	const projectLocation = "/bitrise/src"
+
+	tests := []struct {
+		name    string
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{
+			name:    "legacy pattern",
+			pattern: "*build/app/outputs/apk/*/*.apk",
+			dir:     "/bitrise/src/lib",
+			want:    true,
+		},
+		{
+			name:    "legacy pattern in pruned directory",
+			pattern: "*build/app/outputs/apk/*/*.apk",
+			dir:     "/bitrise/src/node_modules",
+			want:    false,
+		},
+		{
+			name:    "absolute legacy pattern outside of its prefix",
+			pattern: "/bitrise/src/build/*.apk",
+			dir:     "/bitrise/src/lib",
+			want:    false,
+		},
+		{
+			name:    "relative pattern on the pattern path",
+			pattern: "build/app/outputs/bundle/*/*.aab",
+			dir:     "/bitrise/src/build/app/outputs",
+			want:    true,
+		},
+		{
+			name:    "relative pattern outside of the pattern path",
+			pattern: "build/app/outputs/bundle/*/*.aab",
+			dir:     "/bitrise/src/ios",
+			want:    false,
+		},
+		{
+			name:    "relative pattern deeper than the pattern",
+			pattern: "build/app/outputs/bundle/*/*.aab",
+			dir:     "/bitrise/src/build/app/outputs/bundle/release/sub",
+			want:    false,
+		},
+		{
+			name:    "doublestar pattern",
+			pattern: "build/**/*.aab",
+			dir:     "/bitrise/src/build/app/intermediates",
+			want:    true,
+		},
+		{
+			name:    "doublestar pattern in pruned directory",
+			pattern: "**/*.app",
+			dir:     "/bitrise/src/ios/Pods",
+			want:    false,
+		},
+		{
+			name:    "pattern explicitly referring to a pruned directory",
+			pattern: "ios/Pods/**/*.app",
+			dir:     "/bitrise/src/ios/Pods",
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := newOutputPattern(projectLocation, tt.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, pattern.mayMatchIn(tt.dir))
+		})
+	}
+}
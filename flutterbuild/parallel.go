@@ -0,0 +1,142 @@
+package flutterbuild
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// ParallelOutput is how the output of concurrent builds is printed.
+type ParallelOutput string
+
+const (
+	// ParallelOutputPrefixed prints the output lines as they arrive, prefixed with the platform's name
+	ParallelOutputPrefixed ParallelOutput = "prefixed"
+	// ParallelOutputBuffered prints the output of a build at once, after it finished
+	ParallelOutputBuffered ParallelOutput = "buffered"
+)
+
+// ParallelExport is which artifacts are exported if some of the concurrent builds fail.
+type ParallelExport string
+
+const (
+	// ParallelExportAllSucceeded exports artifacts only if every build succeeded
+	ParallelExportAllSucceeded ParallelExport = "all_succeeded"
+	// ParallelExportPartial exports the artifacts of the succeeded builds
+	ParallelExportPartial ParallelExport = "partial"
+)
+
+type buildResult struct {
+	spec     BuildSpecification
+	duration time.Duration
+	err      error
+}
+
+// buildConcurrently runs the builds concurrently and returns their results in the order of specs.
+func buildConcurrently(specs []BuildSpecification, outputMode ParallelOutput) []buildResult {
+	var outputMu sync.Mutex
+	results := make([]buildResult, len(specs))
+
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		go func(i int, spec BuildSpecification) {
+			defer wg.Done()
+
+			var flush func()
+			if outputMode == ParallelOutputBuffered {
+				buffer := &lockedBuffer{}
+				spec.stdout, spec.stderr = buffer, buffer
+				flush = func() {
+					outputMu.Lock()
+					defer outputMu.Unlock()
+
+					fmt.Println()
+					log.Infof(spec.displayName + " build output")
+					if _, err := os.Stdout.Write(buffer.Bytes()); err != nil {
+						log.Warnf("Failed to print %s build output: %s", spec.displayName, err)
+					}
+				}
+			} else {
+				writer := newPrefixWriter(os.Stdout, "["+spec.displayName+"] ", &outputMu)
+				spec.stdout, spec.stderr = writer, writer
+				flush = writer.flush
+			}
+
+			startTime := time.Now()
+			err := spec.Build()
+			flush()
+
+			results[i] = buildResult{spec: spec, duration: time.Since(startTime), err: err}
+		}(i, spec)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// prefixWriter writes complete lines prefixed to w, mu serializes the writes of the prefixWriters sharing w.
+type prefixWriter struct {
+	w       io.Writer
+	prefix  string
+	mu      *sync.Mutex
+	pending []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{w: w, prefix: prefix, mu: mu}
+}
+
+func (writer *prefixWriter) Write(p []byte) (int, error) {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	writer.pending = append(writer.pending, p...)
+	for {
+		i := bytes.IndexByte(writer.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := writer.w.Write([]byte(writer.prefix + string(writer.pending[:i+1]))); err != nil {
+			return 0, err
+		}
+		writer.pending = writer.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush writes the last, unterminated line.
+func (writer *prefixWriter) flush() {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+
+	if len(writer.pending) > 0 {
+		if _, err := writer.w.Write([]byte(writer.prefix + string(writer.pending) + "\n")); err != nil {
+			log.Warnf("Failed to write build output: %s", err)
+		}
+		writer.pending = nil
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (buffer *lockedBuffer) Write(p []byte) (int, error) {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	return buffer.buf.Write(p)
+}
+
+func (buffer *lockedBuffer) Bytes() []byte {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	return buffer.buf.Bytes()
+}
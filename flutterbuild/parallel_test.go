@@ -0,0 +1,31 @@
+package flutterbuild
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_prefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mu sync.Mutex
+	ios := newPrefixWriter(&out, "[iOS app] ", &mu)
+	android := newPrefixWriter(&out, "[Android app] ", &mu)
+
+	_, err := ios.Write([]byte("Running pod install...\nBuilding"))
+	require.NoError(t, err)
+	_, err = android.Write([]byte("Running Gradle task 'assembleRelease'...\n"))
+	require.NoError(t, err)
+	_, err = ios.Write([]byte(" App.framework\nXcode build done"))
+	require.NoError(t, err)
+	ios.flush()
+	android.flush()
+
+	require.Equal(t, `[iOS app] Running pod install...
+[Android app] Running Gradle task 'assembleRelease'...
+[iOS app] Building App.framework
+[iOS app] Xcode build done
+`, out.String())
+}
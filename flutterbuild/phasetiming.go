@@ -0,0 +1,46 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// phaseTimings records the duration of the Step's phases in the order they were started.
+type phaseTimings struct {
+	phases []phaseTiming
+}
+
+// start starts timing a phase, the returned function records its duration.
+func (timings *phaseTimings) start(name string) func() {
+	startTime := time.Now()
+	return func() {
+		timings.add(name, time.Since(startTime))
+	}
+}
+
+func (timings *phaseTimings) add(name string, duration time.Duration) {
+	timings.phases = append(timings.phases, phaseTiming{name: name, duration: duration})
+}
+
+func (timings phaseTimings) print() {
+	if len(timings.phases) == 0 {
+		return
+	}
+
+	fmt.Println()
+	log.Infof("Phase durations")
+
+	var total time.Duration
+	for _, phase := range timings.phases {
+		log.Printf("- %s: %s", phase.name, phase.duration.Round(time.Second))
+		total += phase.duration
+	}
+	log.Printf("Total: %s", total.Round(time.Second))
+}
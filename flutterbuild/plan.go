@@ -0,0 +1,151 @@
+package flutterbuild
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+// BuildPlan is what Run and Export would do with a BuildConfig.
+type BuildPlan struct {
+	// FlutterExecutable is the resolved path of flutter, empty if it is not found
+	FlutterExecutable string         `json:"flutter_executable"`
+	ProjectLocation   string         `json:"project_location"`
+	Workspace         string         `json:"workspace,omitempty"`
+	CleanMode         CleanMode      `json:"clean_mode"`
+	PreBuildPhases    []string       `json:"pre_build_phases,omitempty"`
+	Builds            []PlannedBuild `json:"builds"`
+	// Cache is nil if the cache collection is disabled
+	Cache *PlannedCache `json:"cache,omitempty"`
+}
+
+// PlannedBuild is the build of a platform.
+type PlannedBuild struct {
+	Platform   string     `json:"platform"`
+	OutputType OutputType `json:"output_type"`
+	Command    string     `json:"command"`
+	// Env are the environment variables set for the build command, with the Gradle project properties redacted
+	Env            []string `json:"env,omitempty"`
+	OutputPatterns []string `json:"output_patterns"`
+	// MatchingArtifacts are the artifacts of the output type the output patterns match in the current project tree
+	MatchingArtifacts []string `json:"matching_artifacts"`
+}
+
+// PlannedCache are the paths the cache collectors include in and exclude from the cache.
+type PlannedCache struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// Plan returns the BuildPlan of cfg, it neither runs flutter nor exports outputs.
+func (builder FlutterBuilder) Plan(cfg BuildConfig) (BuildPlan, error) {
+	plan := BuildPlan{
+		ProjectLocation: cfg.projectLocation,
+		Workspace:       cfg.workspaceDir(),
+		CleanMode:       cfg.cleanMode,
+		Builds:          []PlannedBuild{},
+	}
+
+	flutterPth, err := builder.runner.LookPath("flutter")
+	if err != nil {
+		log.Warnf("Failed to find the Flutter executable: %s", err)
+	}
+	plan.FlutterExecutable = flutterPth
+
+	for _, phase := range cfg.preBuildPhases {
+		plan.PreBuildPhases = append(plan.PreBuildPhases, Command{Name: phase.args[0], Args: phase.args[1:]}.printableCommandArgs())
+	}
+
+	for _, spec := range cfg.specs {
+		args, err := spec.buildArgs()
+		if err != nil {
+			return BuildPlan{}, newStepError(PhaseProcessConfig, "failed to parse %s build parameters: %s", spec.displayName, err)
+		}
+
+		artifacts, err := spec.ArtifactPaths()
+		if err != nil {
+			return BuildPlan{}, newStepError(PhaseProcessConfig, "failed to find %s artifacts: %s", spec.displayName, err)
+		}
+		if artifacts == nil {
+			artifacts = []string{}
+		}
+
+		var env []string
+		for _, e := range spec.env {
+			env = append(env, printableGradleEnv(e))
+		}
+
+		plan.Builds = append(plan.Builds, PlannedBuild{
+			Platform:          spec.displayName,
+			OutputType:        spec.platformOutputType,
+			Command:           spec.printableCommand(args),
+			Env:               env,
+			OutputPatterns:    spec.outputPathPatterns,
+			MatchingArtifacts: artifacts,
+		})
+	}
+
+	if cfg.cacheLevel == "all" {
+		items := collectCacheItems(cfg.projectLocation, cfg.workspaceDir())
+		plan.Cache = &PlannedCache{Include: items.include, Exclude: items.exclude}
+		if plan.Cache.Include == nil {
+			plan.Cache.Include = []string{}
+		}
+		if plan.Cache.Exclude == nil {
+			plan.Cache.Exclude = []string{}
+		}
+	}
+
+	return plan, nil
+}
+
+// Print logs the plan.
+func (plan BuildPlan) Print() {
+	fmt.Println()
+	log.Infof("Build plan")
+
+	if plan.FlutterExecutable != "" {
+		log.Printf("Flutter executable: %s", plan.FlutterExecutable)
+	} else {
+		log.Warnf("Flutter executable: not found in $PATH")
+	}
+	log.Printf("Project location: %s", plan.ProjectLocation)
+	if plan.Workspace != "" {
+		log.Printf("Workspace: %s", plan.Workspace)
+	}
+	log.Printf("Clean mode: %s", plan.CleanMode)
+
+	if len(plan.PreBuildPhases) > 0 {
+		log.Printf("Pre-build phases:")
+		for _, phase := range plan.PreBuildPhases {
+			log.Printf("- $ %s", phase)
+		}
+	}
+
+	for _, build := range plan.Builds {
+		fmt.Println()
+		log.Infof("Build %s", build.Platform)
+		for _, env := range build.Env {
+			log.Printf("%s", env)
+		}
+		log.Printf("$ %s", build.Command)
+		log.Printf("Output patterns: %v", build.OutputPatterns)
+		if len(build.MatchingArtifacts) == 0 {
+			log.Warnf("No %s artifact matches the output patterns in the current project tree", build.OutputType)
+		}
+		for _, artifact := range build.MatchingArtifacts {
+			log.Printf("- %s", artifact)
+		}
+	}
+
+	if plan.Cache != nil {
+		fmt.Println()
+		log.Infof("Cache")
+		for _, pth := range plan.Cache.Include {
+			log.Printf("+ %s", pth)
+		}
+		for _, pth := range plan.Cache.Exclude {
+			log.Printf("- %s", pth)
+		}
+	}
+}
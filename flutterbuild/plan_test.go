@@ -0,0 +1,54 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlutterBuilder_Plan(t *testing.T) {
+	t.Setenv("BITRISE_CACHE_INCLUDE_PATHS", "")
+	t.Setenv("BITRISE_CACHE_EXCLUDE_PATHS", "")
+	t.Setenv("GRADLE_OPTS", "")
+
+	projectDir := createTestProject(t)
+	apkDir := filepath.Join(projectDir, "build", "app", "outputs", "apk", "release")
+	require.NoError(t, os.MkdirAll(apkDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(apkDir, "app-release.apk"), nil, 0644))
+
+	runner := &fakeFlutterRunner{t: t}
+	exporter := &fakeExporter{envs: map[string]string{}}
+	builder := NewFlutterBuilder(Dependencies{Runner: runner, Exporter: exporter}, t.TempDir(), t.TempDir())
+
+	cfg := testConfig(projectDir)
+	cfg.Platform = "android"
+	cfg.BuildNumberSource = BuildNumberSourceNone
+	cfg.DartDefines = "API_KEY=secret-key"
+	cfg.PreBuildPhases = []string{"pub_get"}
+	cfg.GradleDaemon = GradleSwitchDisabled
+	cfg.GradleProjectProperties = []string{"storePassword=secret"}
+
+	buildConfig, err := builder.ProcessConfig(cfg)
+	require.NoError(t, err)
+	plan, err := builder.Plan(buildConfig)
+	require.NoError(t, err)
+
+	require.Equal(t, projectDir, plan.ProjectLocation)
+	require.Equal(t, []string{`flutter "pub" "get"`}, plan.PreBuildPhases)
+	require.Equal(t, []PlannedBuild{{
+		Platform:          "Android app",
+		OutputType:        OutputTypeAPK,
+		Command:           `flutter "build" "apk" "--dart-define=API_KEY=[REDACTED]" "--build-name=1.2.3"`,
+		Env:               []string{"GRADLE_OPTS=-Dorg.gradle.daemon=false", "ORG_GRADLE_PROJECT_storePassword=[REDACTED]"},
+		OutputPatterns:    cfg.AndroidExportPattern,
+		MatchingArtifacts: []string{filepath.Join(apkDir, "app-release.apk")},
+	}}, plan.Builds)
+
+	require.NotNil(t, plan.Cache)
+	require.Contains(t, plan.Cache.Include, filepath.Join(projectDir, "ios", "Pods")+" -> "+filepath.Join(projectDir, "ios", "Podfile.lock"))
+
+	require.Empty(t, runner.commands)
+	require.Empty(t, exporter.envs)
+}
@@ -0,0 +1,71 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+const dartRunPrefix = "dart run "
+
+// preBuildPhase is a command (typically code generation) run in the project directory before `flutter build`.
+type preBuildPhase struct {
+	name string
+	args []string
+}
+
+var knownPreBuildPhases = map[string][]string{
+	"pub_get":      {"flutter", "pub", "get"},
+	"build_runner": {"dart", "run", "build_runner", "build", "--delete-conflicting-outputs"},
+	"gen_l10n":     {"flutter", "gen-l10n"},
+}
+
+// parsePreBuildPhases parses the pre_build_phases input.
+// Every line is either a known phase name (pub_get, build_runner, gen_l10n) or a `dart run` command.
+func parsePreBuildPhases(lines []string) ([]preBuildPhase, error) {
+	var phases []preBuildPhase
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if args, ok := knownPreBuildPhases[line]; ok {
+			phases = append(phases, preBuildPhase{name: line, args: args})
+			continue
+		}
+
+		if !strings.HasPrefix(line, dartRunPrefix) {
+			return nil, fmt.Errorf("unknown pre-build phase (%s), use one of pub_get, build_runner, gen_l10n or a `dart run` command", line)
+		}
+
+		args, err := shellquote.Split(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pre-build phase (%s): %s", line, err)
+		}
+		phases = append(phases, preBuildPhase{name: line, args: args})
+	}
+	return phases, nil
+}
+
+func (phase preBuildPhase) run(runner CommandRunner, projectLocation string) error {
+	cmd := Command{Name: phase.args[0], Args: phase.args[1:], Dir: projectLocation, Stdout: os.Stdout, Stderr: os.Stderr}
+
+	fmt.Println()
+	log.Donef("$ %s", cmd.printableCommandArgs())
+	fmt.Println()
+
+	start := time.Now()
+	err := runner.Run(cmd)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("pre-build phase (%s) failed after %s: %s", phase.name, elapsed, err)
+	}
+
+	log.Donef("- %s finished in %s", phase.name, elapsed)
+	return nil
+}
@@ -0,0 +1,52 @@
+package flutterbuild
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parsePreBuildPhases(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []preBuildPhase
+		wantErr bool
+	}{
+		{
+			name:  "empty input",
+			lines: []string{""},
+			want:  nil,
+		},
+		{
+			name:  "known phases and dart run command",
+			lines: []string{"pub_get", " build_runner ", "", "dart run intl_utils:generate --arb-dir 'lib/l10n'"},
+			want: []preBuildPhase{
+				{name: "pub_get", args: []string{"flutter", "pub", "get"}},
+				{name: "build_runner", args: []string{"dart", "run", "build_runner", "build", "--delete-conflicting-outputs"}},
+				{name: "dart run intl_utils:generate --arb-dir 'lib/l10n'", args: []string{"dart", "run", "intl_utils:generate", "--arb-dir", "lib/l10n"}},
+			},
+		},
+		{
+			name:    "unknown phase",
+			lines:   []string{"flutter build apk"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid quoting",
+			lines:   []string{"dart run 'tool"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePreBuildPhases(tt.lines)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
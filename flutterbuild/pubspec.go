@@ -0,0 +1,73 @@
+package flutterbuild
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// https://dart.dev/tools/pub/pubspec#version
+var pubspecVersionRegexp = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+
+type pubspec struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Version     string            `yaml:"version"`
+	Environment map[string]string `yaml:"environment"`
+
+	// Workspace lists the member packages of a pub workspace root
+	Workspace []string `yaml:"workspace"`
+	// Melos is the melos configuration of a workspace root (melos 7+)
+	Melos yaml.Node `yaml:"melos"`
+}
+
+func readPubspec(projectDir string) (pubspec, error) {
+	pth := filepath.Join(projectDir, "pubspec.yaml")
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return pubspec{}, fmt.Errorf("pubspec.yaml not found in %s, check the 'Project Location' input", projectDir)
+		}
+		return pubspec{}, fmt.Errorf("failed to read pubspec.yaml: %s", err)
+	}
+
+	var spec pubspec
+	if err := yaml.Unmarshal(content, &spec); err != nil {
+		return pubspec{}, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return spec, nil
+}
+
+// validate checks the fields flutter build relies on.
+func (spec pubspec) validate() error {
+	if spec.Name == "" {
+		return errors.New("the required 'name' field is missing")
+	}
+	if spec.Version != "" && !pubspecVersionRegexp.MatchString(spec.Version) {
+		return fmt.Errorf("invalid version (%s), expected a semantic version like 1.2.3+4", spec.Version)
+	}
+	return nil
+}
+
+// splitPubspecVersion splits a pubspec.yaml version (like `1.2.3+4`) into the build name and build number.
+func splitPubspecVersion(version string) (string, string) {
+	name, number, _ := strings.Cut(version, "+")
+	return name, number
+}
+
+func exportPubspecMetadata(exporter OutputExporter, spec pubspec) error {
+	versionName, buildNumber := splitPubspecVersion(spec.Version)
+	return exportOutputs(exporter, []envOutput{
+		{"FLUTTER_APP_NAME", spec.Name},
+		{"FLUTTER_APP_DESCRIPTION", spec.Description},
+		{"FLUTTER_APP_VERSION_NAME", versionName},
+		{"FLUTTER_APP_BUILD_NUMBER", buildNumber},
+		{"FLUTTER_APP_SDK_CONSTRAINT", spec.Environment["sdk"]},
+		{"FLUTTER_APP_FLUTTER_CONSTRAINT", spec.Environment["flutter"]},
+	})
+}
@@ -0,0 +1,70 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_readPubspec(t *testing.T) {
+	projectDir := t.TempDir()
+
+	_, err := readPubspec(projectDir)
+	require.EqualError(t, err, "pubspec.yaml not found in "+projectDir+", check the 'Project Location' input")
+
+	content := `name: sample
+description: A sample app.
+version: 1.2.3+4
+environment:
+  sdk: ">=3.0.0 <4.0.0"
+  flutter: ">=3.10.0"
+`
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pubspec.yaml"), []byte(content), 0644))
+
+	spec, err := readPubspec(projectDir)
+	require.NoError(t, err)
+	require.Equal(t, "sample", spec.Name)
+	require.Equal(t, "A sample app.", spec.Description)
+	require.Equal(t, "1.2.3+4", spec.Version)
+	require.Equal(t, map[string]string{"sdk": ">=3.0.0 <4.0.0", "flutter": ">=3.10.0"}, spec.Environment)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "pubspec.yaml"), []byte("name: [sample"), 0644))
+	_, err = readPubspec(projectDir)
+	require.Error(t, err)
+}
+
+func Test_pubspec_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    pubspec
+		wantErr bool
+	}{
+		{name: "valid", spec: pubspec{Name: "app", Version: "1.2.3+4"}},
+		{name: "pre-release version", spec: pubspec{Name: "app", Version: "1.2.3-beta.1+4"}},
+		{name: "no version", spec: pubspec{Name: "app"}},
+		{name: "missing name", spec: pubspec{Version: "1.2.3"}, wantErr: true},
+		{name: "invalid version", spec: pubspec{Name: "app", Version: "1.2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_splitPubspecVersion(t *testing.T) {
+	name, number := splitPubspecVersion("1.2.3+4")
+	require.Equal(t, "1.2.3", name)
+	require.Equal(t, "4", number)
+
+	name, number = splitPubspecVersion("1.2.3")
+	require.Equal(t, "1.2.3", name)
+	require.Equal(t, "", number)
+}
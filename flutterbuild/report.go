@@ -0,0 +1,77 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+type platformStatus string
+
+const (
+	platformSucceeded    platformStatus = "succeeded"
+	platformBuildFailed  platformStatus = "build failed"
+	platformExportFailed platformStatus = "export failed"
+)
+
+type platformResult struct {
+	displayName string
+	status      platformStatus
+	err         error
+}
+
+// buildReport collects the result of every attempted platform.
+type buildReport struct {
+	results []platformResult
+}
+
+func (report *buildReport) add(displayName string, status platformStatus, err error) {
+	report.results = append(report.results, platformResult{displayName: displayName, status: status, err: err})
+}
+
+func (report buildReport) failed() bool {
+	for _, result := range report.results {
+		if result.status != platformSucceeded {
+			return true
+		}
+	}
+	return false
+}
+
+func (report buildReport) print() {
+	fmt.Println()
+	log.Infof("Build report")
+
+	for _, result := range report.results {
+		if result.status == platformSucceeded {
+			log.Donef("- %s: %s", result.displayName, result.status)
+		} else {
+			log.Errorf("- %s: %s: %s", result.displayName, result.status, result.err)
+		}
+	}
+}
+
+// phase returns the Step phase the failures belong to: PhaseRun if a build failed, PhaseExport if only exports failed.
+func (report buildReport) phase() Phase {
+	for _, result := range report.results {
+		if result.status == platformBuildFailed {
+			return PhaseRun
+		}
+	}
+	return PhaseExport
+}
+
+// err returns the combined error of the failed platforms.
+func (report buildReport) err() error {
+	var failures []string
+	for _, result := range report.results {
+		if result.status != platformSucceeded {
+			failures = append(failures, fmt.Sprintf("%s %s: %s", result.displayName, result.status, result.err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(failures, "; "))
+}
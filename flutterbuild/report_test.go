@@ -0,0 +1,24 @@
+package flutterbuild
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildReport(t *testing.T) {
+	var report buildReport
+	report.add("iOS app", platformSucceeded, nil)
+	require.False(t, report.failed())
+	require.NoError(t, report.err())
+
+	report.add("Android app", platformBuildFailed, errors.New("exit status 1"))
+	require.True(t, report.failed())
+	require.EqualError(t, report.err(), "Android app build failed: exit status 1")
+
+	report = buildReport{}
+	report.add("iOS app", platformBuildFailed, ErrCodeSign)
+	report.add("Android app", platformExportFailed, errors.New("failed to find artifacts"))
+	require.EqualError(t, report.err(), "iOS app build failed: CODESIGN; Android app export failed: failed to find artifacts")
+}
@@ -0,0 +1,130 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/bitrise-io/go-steputils/output"
+	"github.com/bitrise-io/go-steputils/tools"
+	"github.com/bitrise-io/go-utils/command"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-xcode/certificateutil"
+)
+
+// Command is an external command run by a CommandRunner.
+type Command struct {
+	Name string
+	Args []string
+	Dir  string
+	// Env are KEY=VALUE environment variables set in addition to the Step's environment
+	Env      []string
+	Stdin    io.Reader
+	Stdout   io.Writer
+	Stderr   io.Writer
+	Timeouts Timeouts
+}
+
+func (cmd Command) printableCommandArgs() string {
+	return command.PrintableCommandArgs(false, append([]string{cmd.Name}, cmd.Args...))
+}
+
+// CommandRunner runs the external commands of the Step (flutter, git, melos, bundletool).
+type CommandRunner interface {
+	// Run runs the command, writing its output to the command's Stdout and Stderr.
+	Run(cmd Command) error
+	// Output runs the command in dir and returns its trimmed combined output.
+	Output(dir, name string, args ...string) (string, error)
+	// LookPath returns the path of the executable name found in $PATH.
+	LookPath(name string) (string, error)
+}
+
+// OutputExporter exports the Step outputs.
+type OutputExporter interface {
+	// ExportEnv exports an environment variable for the subsequent Steps.
+	ExportEnv(key, value string) error
+	// ExportFile copies sourcePath to destinationPath and exports destinationPath as key.
+	ExportFile(sourcePath, destinationPath, key string) error
+}
+
+// Dependencies are the external systems the FlutterBuilder interacts with.
+type Dependencies struct {
+	Runner   CommandRunner
+	Exporter OutputExporter
+	// InstalledCodesignIdentities returns the names of the installed codesign certificates
+	InstalledCodesignIdentities func() ([]string, error)
+}
+
+// DefaultDependencies runs the commands as child processes and exports the outputs with envman.
+func DefaultDependencies() Dependencies {
+	return Dependencies{
+		Runner:                      execCommandRunner{},
+		Exporter:                    envmanExporter{},
+		InstalledCodesignIdentities: certificateutil.InstalledCodesigningCertificateNames,
+	}
+}
+
+// execCommandRunner runs the commands as child processes.
+type execCommandRunner struct{}
+
+func (execCommandRunner) Run(cmd Command) error {
+	model := command.New(cmd.Name, cmd.Args...).
+		SetDir(cmd.Dir).
+		SetStdout(cmd.Stdout).
+		SetStderr(cmd.Stderr)
+	if cmd.Stdin != nil {
+		model.SetStdin(cmd.Stdin)
+	}
+	if len(cmd.Env) > 0 {
+		model.AppendEnvs(cmd.Env...)
+	}
+	return runWithTimeouts(model.GetCmd(), cmd.Timeouts)
+}
+
+func (execCommandRunner) Output(dir, name string, args ...string) (string, error) {
+	return command.New(name, args...).SetDir(dir).RunAndReturnTrimmedCombinedOutput()
+}
+
+func (execCommandRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// envOutput is an output environment variable of the Step.
+type envOutput struct {
+	key   string
+	value string
+}
+
+// exportOutputs exports the outputs as environment variables and logs their values.
+func exportOutputs(exporter OutputExporter, outputs []envOutput) error {
+	for _, output := range outputs {
+		if err := exporter.ExportEnv(output.key, output.value); err != nil {
+			return err
+		}
+		log.Donef("- %s: %s", output.key, output.value)
+	}
+	return nil
+}
+
+// envmanExporter exports the outputs with envman.
+type envmanExporter struct{}
+
+func (envmanExporter) ExportEnv(key, value string) error {
+	if err := tools.ExportEnvironmentWithEnvman(key, value); err != nil {
+		return fmt.Errorf("failed to export enviroment variable %s, error: %s", key, err)
+	}
+	return nil
+}
+
+func (envmanExporter) ExportFile(sourcePath, destinationPath, key string) error {
+	return output.ExportOutputFile(sourcePath, destinationPath, key)
+}
+
+// exporterVariableSetter adapts an OutputExporter to the cache package's VariableSetter.
+type exporterVariableSetter struct {
+	exporter OutputExporter
+}
+
+func (setter exporterVariableSetter) Set(key, value string) error {
+	return setter.exporter.ExportEnv(key, value)
+}
@@ -0,0 +1,388 @@
+package flutterbuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const sizeAnalysisTopPackageCount = 10
+
+// sizeBudgets are the maximum allowed sizes in bytes, zero values are not enforced.
+type sizeBudgets struct {
+	artifact int64
+	dartAOT  int64
+}
+
+// sizeAnalysisConfig configures building with `--analyze-size`.
+const (
+	// sizeAnalysisReportDefaultSuffix follows the platform in the deployed report's name if no artifact name template is set
+	sizeAnalysisReportDefaultSuffix = "-code-size-analysis.json"
+	sizeAnalysisReportExt           = ".code-size-analysis.json"
+)
+
+type sizeAnalysisConfig struct {
+	// codeSizeDir is passed as --code-size-directory, flutter writes the analysis JSON into it
+	codeSizeDir string
+	// targetPlatform is the single --target-platform required by Android size analysis
+	targetPlatform string
+	budgets        sizeBudgets
+	// baselinePath is the analysis JSON of a previous build, or a directory with the reports deployed by a previous build
+	baselinePath string
+	// growthThreshold is the maximum allowed size growth in percent compared to the baseline, zero disables the check
+	growthThreshold float64
+}
+
+func (cfg sizeAnalysisConfig) buildArgs() []string {
+	args := []string{"--analyze-size", "--code-size-directory=" + cfg.codeSizeDir}
+	if cfg.targetPlatform != "" {
+		args = append(args, "--target-platform="+cfg.targetPlatform)
+	}
+	return args
+}
+
+// sizeAnalysisNode is a node of the code size analysis JSON tree written by `flutter build --analyze-size`.
+type sizeAnalysisNode struct {
+	Name     string             `json:"n"`
+	Value    int64              `json:"value"`
+	Children []sizeAnalysisNode `json:"children"`
+}
+
+func (node sizeAnalysisNode) size() int64 {
+	if node.Value != 0 || len(node.Children) == 0 {
+		return node.Value
+	}
+	var size int64
+	for _, child := range node.Children {
+		size += child.size()
+	}
+	return size
+}
+
+// find returns the first node in the tree, in depth-first order, for which match returns true.
+func (node sizeAnalysisNode) find(match func(sizeAnalysisNode) bool) (sizeAnalysisNode, bool) {
+	if match(node) {
+		return node, true
+	}
+	for _, child := range node.Children {
+		if found, ok := child.find(match); ok {
+			return found, true
+		}
+	}
+	return sizeAnalysisNode{}, false
+}
+
+type sizeAnalysisReport struct {
+	Type string `json:"type"`
+	sizeAnalysisNode
+}
+
+// packageSize is the size of a Dart package in the AOT snapshot.
+type packageSize struct {
+	name string
+	size int64
+}
+
+// sizeSummary is the summary of a code size analysis report.
+type sizeSummary struct {
+	artifactType string
+	total        int64
+	dartAOT      int64
+	packages     []packageSize
+}
+
+// findSizeAnalysisReport returns the most recent *-code-size-analysis_*.json in dir.
+func findSizeAnalysisReport(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*-code-size-analysis_*.json"))
+	if err != nil {
+		return "", err
+	}
+
+	var newest string
+	var newestInfo os.FileInfo
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			return "", err
+		}
+		if newestInfo == nil || info.ModTime().After(newestInfo.ModTime()) {
+			newest, newestInfo = match, info
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no code size analysis report found in %s", dir)
+	}
+	return newest, nil
+}
+
+func readSizeAnalysisReport(pth string) (sizeAnalysisReport, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return sizeAnalysisReport{}, err
+	}
+
+	var report sizeAnalysisReport
+	if err := json.Unmarshal(content, &report); err != nil {
+		return sizeAnalysisReport{}, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return report, nil
+}
+
+// summarizeSizeAnalysis returns the total size, the Dart AOT snapshot size and its packages ordered by size.
+func summarizeSizeAnalysis(report sizeAnalysisReport) sizeSummary {
+	summary := sizeSummary{
+		artifactType: report.Type,
+		total:        report.size(),
+	}
+
+	aot, ok := report.find(func(node sizeAnalysisNode) bool {
+		return strings.HasSuffix(node.Name, "(Dart AOT)")
+	})
+	if !ok {
+		return summary
+	}
+
+	summary.dartAOT = aot.size()
+	for _, child := range aot.Children {
+		summary.packages = append(summary.packages, packageSize{name: child.Name, size: child.size()})
+	}
+	sort.SliceStable(summary.packages, func(i, j int) bool {
+		return summary.packages[i].size > summary.packages[j].size
+	})
+	return summary
+}
+
+func (summary sizeSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Artifact type: %s\n", summary.artifactType)
+	fmt.Fprintf(&b, "Total size: %s\n", formatSize(summary.total))
+	fmt.Fprintf(&b, "Dart AOT snapshot size: %s\n", formatSize(summary.dartAOT))
+
+	packages := summary.packages
+	if len(packages) > sizeAnalysisTopPackageCount {
+		packages = packages[:sizeAnalysisTopPackageCount]
+	}
+	if len(packages) > 0 {
+		fmt.Fprintf(&b, "Top %d packages:\n", len(packages))
+		for _, pkg := range packages {
+			fmt.Fprintf(&b, "  %-40s %10s\n", pkg.name, formatSize(pkg.size))
+		}
+	}
+	return b.String()
+}
+
+// check returns an error listing the exceeded budgets.
+func (summary sizeSummary) check(budgets sizeBudgets) error {
+	var exceeded []string
+	if budgets.artifact > 0 && summary.total > budgets.artifact {
+		exceeded = append(exceeded, fmt.Sprintf("total size %s exceeds the budget of %s", formatSize(summary.total), formatSize(budgets.artifact)))
+	}
+	if budgets.dartAOT > 0 && summary.dartAOT > budgets.dartAOT {
+		exceeded = append(exceeded, fmt.Sprintf("Dart AOT snapshot size %s exceeds the budget of %s", formatSize(summary.dartAOT), formatSize(budgets.dartAOT)))
+	}
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("%s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+}
+
+// parseSize parses sizes like 1048576, 512KB or 25 MB, units are 1024 based.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	unitStart := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	number, unit := s, ""
+	if unitStart != -1 {
+		number, unit = s[:unitStart], strings.ToUpper(strings.TrimSpace(s[unitStart:]))
+	}
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size (%s), unknown unit: %s", s, unit)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size (%s): %s", s, err)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.2f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// baselineReportPath returns the previous analysis JSON of the platform.
+// In a baseline directory the report is looked up by the name exportSizeAnalysis deploys it as.
+func (spec BuildSpecification) baselineReportPath(platform string) (string, error) {
+	baselinePath := spec.sizeAnalysis.baselinePath
+	info, err := os.Stat(baselinePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read size baseline: %s", err)
+	}
+	if !info.IsDir() {
+		return baselinePath, nil
+	}
+
+	name, err := spec.artifactNamer().name("the size baseline", platform+sizeAnalysisReportDefaultSuffix, sizeAnalysisReportExt, spec.artifactName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baselinePath, name), nil
+}
+
+// sizeDelta is the size change of an artifact, the Dart AOT snapshot or a package compared to the baseline.
+type sizeDelta struct {
+	name     string
+	previous int64
+	current  int64
+}
+
+func (delta sizeDelta) bytes() int64 {
+	return delta.current - delta.previous
+}
+
+// percent returns the growth in percent, or 0 if there is no previous size to compare to.
+func (delta sizeDelta) percent() float64 {
+	if delta.previous == 0 {
+		return 0
+	}
+	return float64(delta.bytes()) / float64(delta.previous) * 100
+}
+
+// compareSizeSummaries returns the total and Dart AOT snapshot size deltas, followed by the changed packages
+// ordered by the absolute size of their change.
+func compareSizeSummaries(previous, current sizeSummary) []sizeDelta {
+	deltas := []sizeDelta{
+		{name: "Total", previous: previous.total, current: current.total},
+		{name: "Dart AOT snapshot", previous: previous.dartAOT, current: current.dartAOT},
+	}
+
+	packages := map[string]*sizeDelta{}
+	var names []string
+	for _, pkg := range previous.packages {
+		packages[pkg.name] = &sizeDelta{name: pkg.name, previous: pkg.size}
+		names = append(names, pkg.name)
+	}
+	for _, pkg := range current.packages {
+		if _, ok := packages[pkg.name]; !ok {
+			packages[pkg.name] = &sizeDelta{name: pkg.name}
+			names = append(names, pkg.name)
+		}
+		packages[pkg.name].current = pkg.size
+	}
+
+	var packageDeltas []sizeDelta
+	for _, name := range names {
+		if delta := *packages[name]; delta.bytes() != 0 {
+			packageDeltas = append(packageDeltas, delta)
+		}
+	}
+	sort.SliceStable(packageDeltas, func(i, j int) bool {
+		return abs(packageDeltas[i].bytes()) > abs(packageDeltas[j].bytes())
+	})
+
+	return append(deltas, packageDeltas...)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func formatSizeDeltas(deltas []sizeDelta) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-40s %12s %12s %12s %9s\n", "", "Previous", "Current", "Delta", "Delta %")
+	for _, delta := range deltas {
+		sign := ""
+		if delta.bytes() > 0 {
+			sign = "+"
+		}
+		percent := "-"
+		if delta.previous != 0 {
+			percent = fmt.Sprintf("%s%.2f%%", sign, delta.percent())
+		}
+		fmt.Fprintf(&b, "%-40s %12s %12s %12s %9s\n", delta.name, formatSize(delta.previous), formatSize(delta.current),
+			sign+formatSignedSize(delta.bytes()), percent)
+	}
+	return b.String()
+}
+
+func formatSignedSize(size int64) string {
+	if size < 0 {
+		return "-" + formatSize(-size)
+	}
+	return formatSize(size)
+}
+
+// checkSizeGrowth returns an error if the total or the Dart AOT snapshot size grew more than threshold percent.
+func checkSizeGrowth(deltas []sizeDelta, threshold float64) error {
+	if threshold <= 0 {
+		return nil
+	}
+
+	var exceeded []string
+	for _, delta := range deltas[:2] {
+		if delta.percent() > threshold {
+			exceeded = append(exceeded, fmt.Sprintf("%s size grew by %.2f%%, more than the allowed %.2f%%", delta.name, delta.percent(), threshold))
+		}
+	}
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("%s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// exportSizeAnalysis writes the summary and the analysis report into the deploy dir
+// and exports the path of the report. The files are named by the artifact name template if it is set.
+func (spec BuildSpecification) exportSizeAnalysis(reportPth string, summary sizeSummary, platform string) error {
+	summaryPth, err := spec.deployPath(reportPth, platform+"-size-analysis-summary.txt", ".size-analysis-summary.txt", "")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(summaryPth, []byte(summary.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write size analysis summary: %s", err)
+	}
+	log.Donef("- Size analysis summary: %s", summaryPth)
+
+	envName := "FLUTTER_" + strings.ToUpper(platform) + "_SIZE_ANALYSIS_PATH"
+	deployedReportPth, err := spec.deployPath(reportPth, platform+sizeAnalysisReportDefaultSuffix, sizeAnalysisReportExt, "")
+	if err != nil {
+		return err
+	}
+	if err := spec.exporter.ExportFile(reportPth, deployedReportPth, envName); err != nil {
+		return err
+	}
+	log.Donef("- %s: %s", envName, deployedReportPth)
+	return nil
+}
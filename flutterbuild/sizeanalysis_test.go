@@ -0,0 +1,187 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSizeAnalysisReport = `{
+  "type": "apk",
+  "n": "app-release.apk",
+  "value": 9000000,
+  "children": [
+    {"n": "assets", "value": 1000000},
+    {
+      "n": "lib",
+      "children": [
+        {
+          "n": "arm64-v8a",
+          "children": [
+            {"n": "libflutter.so", "value": 3000000},
+            {
+              "n": "libapp.so (Dart AOT)",
+              "children": [
+                {"n": "dart:core", "value": 200000},
+                {"n": "package:flutter", "value": 2500000},
+                {"n": "package:sample", "children": [{"n": "main.dart", "value": 100000}, {"n": "home.dart", "value": 50000}]}
+              ]
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func Test_summarizeSizeAnalysis(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "apk-code-size-analysis_01.json")
+	require.NoError(t, os.WriteFile(pth, []byte(testSizeAnalysisReport), 0644))
+
+	found, err := findSizeAnalysisReport(dir)
+	require.NoError(t, err)
+	require.Equal(t, pth, found)
+
+	report, err := readSizeAnalysisReport(found)
+	require.NoError(t, err)
+
+	summary := summarizeSizeAnalysis(report)
+	require.Equal(t, sizeSummary{
+		artifactType: "apk",
+		total:        9000000,
+		dartAOT:      2850000,
+		packages: []packageSize{
+			{name: "package:flutter", size: 2500000},
+			{name: "dart:core", size: 200000},
+			{name: "package:sample", size: 150000},
+		},
+	}, summary)
+
+	require.NoError(t, summary.check(sizeBudgets{}))
+	require.NoError(t, summary.check(sizeBudgets{artifact: 9000000, dartAOT: 3000000}))
+	require.EqualError(t, summary.check(sizeBudgets{artifact: 8 << 20, dartAOT: 2 << 20}),
+		"total size 8.58 MB exceeds the budget of 8.00 MB, Dart AOT snapshot size 2.72 MB exceeds the budget of 2.00 MB")
+
+	_, err = findSizeAnalysisReport(t.TempDir())
+	require.Error(t, err)
+}
+
+func Test_parseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "", want: 0},
+		{size: "1024", want: 1024},
+		{size: "512KB", want: 512 << 10},
+		{size: "25 MB", want: 25 << 20},
+		{size: "1.5mb", want: 3 << 19},
+		{size: "1GB", want: 1 << 30},
+		{size: "10 parsecs", wantErr: true},
+		{size: "MB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseSize(tt.size)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_compareSizeSummaries(t *testing.T) {
+	previous := sizeSummary{
+		total:   1000,
+		dartAOT: 400,
+		packages: []packageSize{
+			{name: "package:flutter", size: 300},
+			{name: "package:removed", size: 60},
+			{name: "dart:core", size: 40},
+		},
+	}
+	current := sizeSummary{
+		total:   1100,
+		dartAOT: 500,
+		packages: []packageSize{
+			{name: "package:flutter", size: 310},
+			{name: "package:added", size: 150},
+			{name: "dart:core", size: 40},
+		},
+	}
+
+	deltas := compareSizeSummaries(previous, current)
+	require.Equal(t, []sizeDelta{
+		{name: "Total", previous: 1000, current: 1100},
+		{name: "Dart AOT snapshot", previous: 400, current: 500},
+		{name: "package:added", previous: 0, current: 150},
+		{name: "package:removed", previous: 60, current: 0},
+		{name: "package:flutter", previous: 300, current: 310},
+	}, deltas)
+
+	require.NoError(t, checkSizeGrowth(deltas, 0))
+	require.NoError(t, checkSizeGrowth(deltas, 25))
+	require.EqualError(t, checkSizeGrowth(deltas, 5),
+		"Total size grew by 10.00%, more than the allowed 5.00%, Dart AOT snapshot size grew by 25.00%, more than the allowed 5.00%")
+}
+
+func TestBuildSpecification_exportSizeAnalysis(t *testing.T) {
+	reportPth := filepath.Join(t.TempDir(), "apk-code-size-analysis_01.json")
+	require.NoError(t, os.WriteFile(reportPth, []byte(testSizeAnalysisReport), 0644))
+	report, err := readSizeAnalysisReport(reportPth)
+	require.NoError(t, err)
+	summary := summarizeSizeAnalysis(report)
+
+	deployDir := t.TempDir()
+	exporter := &fakeExporter{envs: map[string]string{}}
+	spec := BuildSpecification{
+		exporter:     exporter,
+		deployDir:    deployDir,
+		namer:        newArtifactNamer("{app_name}-{flavor}", deployDir),
+		artifactName: artifactNameVariables{appName: "my_app", flavor: "prod"},
+	}
+	require.NoError(t, spec.exportSizeAnalysis(reportPth, summary, "android"))
+	require.FileExists(t, filepath.Join(deployDir, "my_app-prod.size-analysis-summary.txt"))
+	require.Equal(t, filepath.Join(deployDir, "my_app-prod.code-size-analysis.json"), exporter.envs["FLUTTER_ANDROID_SIZE_ANALYSIS_PATH"])
+
+	otherReportPth := filepath.Join(t.TempDir(), "apk-code-size-analysis_01.json")
+	require.NoError(t, os.WriteFile(otherReportPth, []byte(testSizeAnalysisReport), 0644))
+	require.EqualError(t, spec.exportSizeAnalysis(otherReportPth, summary, "android"), reportPth+" and "+otherReportPth+" would both be deployed as my_app-prod.size-analysis-summary.txt, use an artifact name template with variables distinguishing them")
+
+	spec.namer = nil
+	require.NoError(t, spec.exportSizeAnalysis(reportPth, summary, "ios"))
+	require.FileExists(t, filepath.Join(deployDir, "ios-size-analysis-summary.txt"))
+	require.Equal(t, filepath.Join(deployDir, "ios-code-size-analysis.json"), exporter.envs["FLUTTER_IOS_SIZE_ANALYSIS_PATH"])
+}
+
+func TestBuildSpecification_baselineReportPath(t *testing.T) {
+	baselineDir := t.TempDir()
+	baselineReportPth := filepath.Join(baselineDir, "android-code-size-analysis.json")
+	require.NoError(t, os.WriteFile(baselineReportPth, []byte(testSizeAnalysisReport), 0644))
+
+	spec := BuildSpecification{
+		deployDir:    t.TempDir(),
+		sizeAnalysis: &sizeAnalysisConfig{baselinePath: baselineDir},
+		artifactName: artifactNameVariables{appName: "my_app", flavor: "prod", platform: "android"},
+	}
+	pth, err := spec.baselineReportPath("android")
+	require.NoError(t, err)
+	require.Equal(t, baselineReportPth, pth)
+
+	spec.namer = newArtifactNamer("{app_name}-{flavor}-{platform}", spec.deployDir)
+	pth, err = spec.baselineReportPath("android")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(baselineDir, "my_app-prod-android.code-size-analysis.json"), pth)
+
+	spec.sizeAnalysis.baselinePath = baselineReportPth
+	pth, err = spec.baselineReportPath("android")
+	require.NoError(t, err)
+	require.Equal(t, baselineReportPth, pth)
+}
@@ -0,0 +1,647 @@
+package flutterbuild
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+// FlutterBuilder is the Step: ProcessConfig validates the inputs, Run builds the selected platforms
+// and Export exports the artifacts, the app metadata and the cache paths.
+type FlutterBuilder struct {
+	runner                      CommandRunner
+	exporter                    OutputExporter
+	installedCodesignIdentities func() ([]string, error)
+	// flutterSettingsPath is the Flutter tool's settings file, storing the iOS codesign identity
+	flutterSettingsPath string
+	// deployDir receives the exported artifacts
+	deployDir string
+}
+
+// NewFlutterBuilder returns a FlutterBuilder storing the Flutter settings in homeDir and exporting the artifacts into deployDir.
+func NewFlutterBuilder(deps Dependencies, homeDir, deployDir string) FlutterBuilder {
+	return FlutterBuilder{
+		runner:                      deps.Runner,
+		exporter:                    deps.Exporter,
+		installedCodesignIdentities: deps.InstalledCodesignIdentities,
+		flutterSettingsPath:         filepath.Join(homeDir, ".flutter_settings"),
+		deployDir:                   deployDir,
+	}
+}
+
+// BuildConfig is the validated configuration returned by ProcessConfig.
+type BuildConfig struct {
+	projectLocation    string
+	platform           string
+	pubspec            pubspec
+	buildName          string
+	buildNumber        string
+	cleanMode          CleanMode
+	workspace          *workspace
+	workspaceBootstrap bool
+	preBuildPhases     []preBuildPhase
+	// codesignSkipReason is set if the iOS codesign identities are not checked before the build
+	codesignSkipReason string
+	// codesignIdentity overrides the iOS codesign identity stored in the Flutter settings
+	codesignIdentity string
+	// specs are the builds of the selected platforms
+	specs           []BuildSpecification
+	parallelBuilds  bool
+	parallelOutput  ParallelOutput
+	parallelExport  ParallelExport
+	continueOnError bool
+	cacheLevel      string
+}
+
+// RunOutput is the result of Run.
+type RunOutput struct {
+	// built are the builds whose artifacts are exported
+	built   []BuildSpecification
+	report  buildReport
+	timings phaseTimings
+}
+
+// Specs returns the builds of the selected platforms.
+func (cfg BuildConfig) Specs() []BuildSpecification {
+	return cfg.specs
+}
+
+func (cfg BuildConfig) workspaceDir() string {
+	if cfg.workspace == nil {
+		return ""
+	}
+	return cfg.workspace.rootDir
+}
+
+func (cfg BuildConfig) buildsIOS() bool {
+	return cfg.platform == "ios" || cfg.platform == "both"
+}
+
+// ProcessConfig validates the inputs and computes the build of every selected platform.
+func (builder FlutterBuilder) ProcessConfig(inputs Inputs) (BuildConfig, error) {
+	preBuildPhases, err := parsePreBuildPhases(inputs.PreBuildPhases)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+
+	projectLocationAbs, err := filepath.Abs(inputs.ProjectLocation)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to get absolute project path of %s: %s", inputs.ProjectLocation, err)
+	}
+
+	exist, err := pathutil.IsDirExists(projectLocationAbs)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to check if project path exists: %s", err)
+	} else if !exist {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "project path does not exist")
+	}
+
+	appPubspec, err := readPubspec(projectLocationAbs)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+	if err := appPubspec.validate(); err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "invalid pubspec.yaml: %s", err)
+	}
+
+	dartDefines, err := parseDartDefines(string(inputs.DartDefines))
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+
+	dartDefineFromFile := inputs.DartDefineFromFile
+	if dartDefineFromFile != "" {
+		if !filepath.IsAbs(dartDefineFromFile) {
+			dartDefineFromFile = filepath.Join(projectLocationAbs, dartDefineFromFile)
+		}
+		// The file's values are not on the command line, it is only read to fail early on an invalid file
+		if _, err := readDartDefineFileValues(dartDefineFromFile); err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+		}
+	}
+	buildArgs := dartDefineArgs(dartDefines, dartDefineFromFile)
+
+	buildNumber, err := resolveBuildNumber(builder.runner, inputs.BuildNumberSource, inputs.BuildNumber, inputs.BuildNumberOffset, projectLocationAbs)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to determine build number: %s", err)
+	}
+	buildName, err := resolveBuildName(builder.runner, inputs.BuildNameSource, appPubspec, projectLocationAbs)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to determine build name: %s", err)
+	}
+	buildArgs = append(buildArgs, buildVersionArgs(buildName, buildNumber)...)
+
+	androidExpectations := androidArtifactExpectations{applicationID: inputs.AndroidExpectedApplicationID}
+	if inputs.AndroidVerifyVersion {
+		pubspecVersionName, pubspecBuildNumber := splitPubspecVersion(appPubspec.Version)
+		androidExpectations.versionName = buildName
+		if androidExpectations.versionName == "" {
+			androidExpectations.versionName = pubspecVersionName
+		}
+		androidExpectations.versionCode = buildNumber
+		if androidExpectations.versionCode == "" {
+			androidExpectations.versionCode = pubspecBuildNumber
+		}
+	}
+
+	androidBuildArgs := buildArgs
+	primaryABI := ""
+	if inputs.AndroidSplitPerABI && inputs.AndroidOutputType == OutputTypeAPK {
+		androidBuildArgs = append(append([]string{}, buildArgs...), "--split-per-abi")
+		primaryABI = inputs.AndroidPrimaryABI
+	}
+
+	iosBuildArgs := buildArgs
+	var iosSizeAnalysis, androidSizeAnalysis *sizeAnalysisConfig
+	if inputs.SizeAnalysis {
+		if primaryABI != "" {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "size analysis can not be used together with split APKs per ABI")
+		}
+
+		var budgets sizeBudgets
+		var err error
+		if budgets.artifact, err = parseSize(inputs.SizeBudgetArtifact); err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+		}
+		if budgets.dartAOT, err = parseSize(inputs.SizeBudgetDartAOT); err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+		}
+
+		var growthThreshold float64
+		if inputs.SizeGrowthThreshold != "" {
+			if growthThreshold, err = strconv.ParseFloat(inputs.SizeGrowthThreshold, 64); err != nil {
+				return BuildConfig{}, newStepError(PhaseProcessConfig, "invalid size growth threshold (%s): %s", inputs.SizeGrowthThreshold, err)
+			}
+		}
+
+		codeSizeDir, err := pathutil.NormalizedOSTempDirPath("code-size")
+		if err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to create code size directory: %s", err)
+		}
+		iosSizeAnalysis = &sizeAnalysisConfig{
+			codeSizeDir:     filepath.Join(codeSizeDir, "ios"),
+			budgets:         budgets,
+			baselinePath:    inputs.SizeBaselinePath,
+			growthThreshold: growthThreshold,
+		}
+		androidSizeAnalysis = &sizeAnalysisConfig{
+			codeSizeDir:     filepath.Join(codeSizeDir, "android"),
+			targetPlatform:  inputs.SizeAnalysisAndroidTargetPlatform,
+			budgets:         budgets,
+			baselinePath:    inputs.SizeBaselinePath,
+			growthThreshold: growthThreshold,
+		}
+		iosBuildArgs = append(append([]string{}, iosBuildArgs...), iosSizeAnalysis.buildArgs()...)
+		androidBuildArgs = append(append([]string{}, androidBuildArgs...), androidSizeAnalysis.buildArgs()...)
+	}
+
+	gradleProjectProperties, err := parseGradleProjectProperties(inputs.GradleProjectProperties)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+	gradle := gradleSettings{
+		jvmMaxHeap:         strings.TrimSpace(inputs.GradleJVMMaxHeap),
+		daemon:             inputs.GradleDaemon,
+		parallel:           inputs.GradleParallel,
+		configurationCache: inputs.GradleConfigurationCache,
+		projectProperties:  gradleProjectProperties,
+	}
+	if err := gradle.validate(); err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+
+	var universalAPK *universalAPKConfig
+	if inputs.GenerateUniversalAPK && inputs.AndroidOutputType == OutputTypeAppBundle {
+		if inputs.BundletoolPath == "" {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "bundletool path is required to generate a universal APK")
+		}
+		keystore := keystoreConfig{
+			url:                string(inputs.KeystoreURL),
+			password:           string(inputs.KeystorePassword),
+			alias:              inputs.KeystoreAlias,
+			privateKeyPassword: string(inputs.PrivateKeyPassword),
+		}
+		if err := keystore.validate(); err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+		}
+		universalAPK = &universalAPKConfig{bundletoolPath: inputs.BundletoolPath, keystore: keystore}
+	}
+
+	iosParams, err := shellquote.Split(inputs.IOSAdditionalParams)
+	if err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to parse iOS additional parameters: %s", err)
+	}
+	codesignSkipReason := ""
+	if sliceutil.IsStringInSlice(noCodesignFlag, iosParams) {
+		codesignSkipReason = fmt.Sprintf("%s parameter set", noCodesignFlag)
+	} else if inputs.IOSOutputType == OutputTypeIOSApp {
+		codesignSkipReason = "output type is iOS app, not xcarchive"
+	}
+
+	var ws *workspace
+	if inputs.WorkspaceBootstrap {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to get working directory: %s", err)
+		}
+		ws, err = findWorkspace(projectLocationAbs, workDir)
+		if err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to detect workspace: %s", err)
+		}
+		if ws != nil {
+			log.Debugf("Project is part of a %s workspace: %s", ws.workspaceType, ws.rootDir)
+		}
+	}
+
+	inactivityTimeout := time.Duration(inputs.BuildInactivityTimeout) * time.Minute
+	buildRetryPolicy := retryPolicy{maxAttempts: inputs.BuildMaxAttempts, signatures: parseRetrySignatures(inputs.BuildRetrySignatures)}
+	buildSpecifications := []BuildSpecification{
+		{
+			displayName:          "iOS app",
+			platformOutputType:   inputs.IOSOutputType,
+			platformSelectors:    []string{"both", "ios"},
+			outputPathPatterns:   inputs.IOSExportPattern,
+			additionalParameters: inputs.AdditionalBuildParams + " " + inputs.IOSAdditionalParams,
+			iosBundleID:          inputs.IOSBundleID,
+			additionalArgs:       iosBuildArgs,
+			sizeAnalysis:         iosSizeAnalysis,
+			timeouts:             Timeouts{Timeout: time.Duration(inputs.IOSBuildTimeout) * time.Minute, InactivityTimeout: inactivityTimeout},
+			retryPolicy:          buildRetryPolicy,
+		},
+		{
+			displayName:          "Android app",
+			platformOutputType:   inputs.AndroidOutputType,
+			platformSelectors:    []string{"both", "android"},
+			outputPathPatterns:   inputs.AndroidExportPattern,
+			additionalParameters: inputs.AdditionalBuildParams + " " + inputs.AndroidAdditionalParams,
+			additionalArgs:       androidBuildArgs,
+			retryPolicy:          buildRetryPolicy,
+			androidExpectations:  androidExpectations,
+			primaryABI:           primaryABI,
+			sizeAnalysis:         androidSizeAnalysis,
+			universalAPK:         universalAPK,
+			env:                  gradle.env(os.Getenv(gradleOptsEnv)),
+			timeouts:             Timeouts{Timeout: time.Duration(inputs.AndroidBuildTimeout) * time.Minute, InactivityTimeout: inactivityTimeout},
+		},
+	}
+
+	if err := validateArtifactNameTemplate(inputs.ArtifactNameTemplate); err != nil {
+		return BuildConfig{}, newStepError(PhaseProcessConfig, "%s", err)
+	}
+	artifactName := artifactNameVariables{appName: appPubspec.Name, versionName: buildName, buildNumber: buildNumber}
+	pubspecVersionName, pubspecBuildNumber := splitPubspecVersion(appPubspec.Version)
+	if artifactName.versionName == "" {
+		artifactName.versionName = pubspecVersionName
+	}
+	if artifactName.buildNumber == "" {
+		artifactName.buildNumber = pubspecBuildNumber
+	}
+	if strings.Contains(inputs.ArtifactNameTemplate, "{"+gitShortSHAVariable+"}") {
+		sha, err := builder.runner.Output(projectLocationAbs, "git", "rev-parse", "--short", "HEAD")
+		if err != nil {
+			return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to get the git commit for the artifact name template: %s", err)
+		}
+		artifactName.gitShortSHA = strings.TrimSpace(sha)
+	}
+	namer := newArtifactNamer(inputs.ArtifactNameTemplate, builder.deployDir)
+
+	var specs []BuildSpecification
+	for _, spec := range buildSpecifications {
+		if spec.buildable(inputs.Platform) {
+			spec.projectLocation = projectLocationAbs
+			spec.deployDir = builder.deployDir
+			spec.runner = builder.runner
+			spec.exporter = builder.exporter
+
+			flavor, mode, err := flutterBuildFlavorAndMode(spec.additionalParameters)
+			if err != nil {
+				return BuildConfig{}, newStepError(PhaseProcessConfig, "failed to parse %s build parameters: %s", spec.displayName, err)
+			}
+			spec.namer = namer
+			spec.artifactName = artifactName
+			spec.artifactName.flavor = flavor
+			spec.artifactName.buildMode = mode
+			spec.artifactName.platform = "ios"
+			if isAndroidOutputType(spec.platformOutputType) {
+				spec.artifactName.platform = "android"
+			}
+			specs = append(specs, spec)
+		}
+	}
+
+	return BuildConfig{
+		projectLocation:    projectLocationAbs,
+		platform:           inputs.Platform,
+		pubspec:            appPubspec,
+		buildName:          buildName,
+		buildNumber:        buildNumber,
+		cleanMode:          inputs.CleanMode,
+		workspace:          ws,
+		workspaceBootstrap: inputs.WorkspaceBootstrap,
+		preBuildPhases:     preBuildPhases,
+		codesignSkipReason: codesignSkipReason,
+		codesignIdentity:   inputs.IOSCodesignIdentity,
+		specs:              specs,
+		parallelBuilds:     inputs.ParallelBuilds,
+		parallelOutput:     inputs.ParallelOutput,
+		parallelExport:     inputs.ParallelExport,
+		continueOnError:    inputs.ContinueOnError,
+		cacheLevel:         inputs.CacheLevel,
+	}, nil
+}
+
+// Run prepares the project and builds the selected platforms.
+// The returned RunOutput lists the builds to export, also if Run fails.
+func (builder FlutterBuilder) Run(cfg BuildConfig) (RunOutput, error) {
+	var out RunOutput
+
+	if cfg.buildsIOS() {
+		fmt.Println()
+		log.Infof("iOS Codesign settings")
+
+		if cfg.codesignSkipReason != "" {
+			log.Printf(" - Skipping codesign preparation because %s", cfg.codesignSkipReason)
+		} else {
+			finishCodesignPrep := out.timings.start("Codesign preparation")
+			err := builder.prepareCodesign(cfg.codesignIdentity)
+			finishCodesignPrep()
+			if err != nil {
+				if !cfg.continueOnError {
+					return out, &StepError{Phase: PhaseRun, Err: err}
+				}
+				log.Errorf("Failed to prepare iOS codesigning: %s", err)
+				cfg.specs = dropIOSSpecs(cfg.specs, err, &out.report)
+			}
+		}
+	}
+
+	if cfg.cleanMode != "" && cfg.cleanMode != CleanModeNone {
+		fmt.Println()
+		log.Infof("Clean project")
+
+		finishClean := out.timings.start("Clean")
+		if err := cleanProject(builder.runner, cfg.projectLocation, cfg.cleanMode); err != nil {
+			return out, newStepError(PhaseRun, "failed to clean project: %s", err)
+		}
+		finishClean()
+	}
+
+	if cfg.workspaceBootstrap {
+		fmt.Println()
+		log.Infof("Bootstrap workspace")
+
+		finishBootstrap := out.timings.start("Workspace bootstrap")
+		if cfg.workspace == nil {
+			log.Printf("- No melos.yaml or pubspec.yaml with a workspace section found in or above %s, skipping", cfg.projectLocation)
+		} else if err := cfg.workspace.bootstrap(builder.runner); err != nil {
+			return out, newStepError(PhaseRun, "failed to bootstrap %s workspace (%s): %s", cfg.workspace.workspaceType, cfg.workspace.rootDir, err)
+		}
+		finishBootstrap()
+	}
+
+	if len(cfg.preBuildPhases) > 0 {
+		fmt.Println()
+		log.Infof("Run pre-build phases")
+
+		finishPreBuild := out.timings.start("Pre-build phases")
+		for _, phase := range cfg.preBuildPhases {
+			if err := phase.run(builder.runner, cfg.projectLocation); err != nil {
+				return out, &StepError{Phase: PhaseRun, Err: err}
+			}
+		}
+		finishPreBuild()
+	}
+
+	if cfg.parallelBuilds && len(cfg.specs) > 1 {
+		return out, builder.buildConcurrently(cfg, &out)
+	}
+	return out, builder.buildSequentially(cfg, &out)
+}
+
+// dropIOSSpecs returns the specs without the iOS builds, which are reported as failed with err.
+func dropIOSSpecs(specs []BuildSpecification, err error, report *buildReport) []BuildSpecification {
+	var kept []BuildSpecification
+	for _, spec := range specs {
+		if isAndroidOutputType(spec.platformOutputType) {
+			kept = append(kept, spec)
+			continue
+		}
+		report.add(spec.displayName, platformBuildFailed, err)
+	}
+	return kept
+}
+
+// warnBuildFailure logs a hint about fixing codesign failures.
+func warnBuildFailure(err error, codesignIdentity string) {
+	if !errors.Is(err, ErrCodeSign) {
+		return
+	}
+	if codesignIdentity != "" {
+		log.Warnf("Invalid codesign identity is selected, choose the appropriate identity in the step's [iOS Platform Configs>Codesign Identity] input field.")
+	} else {
+		log.Warnf("You have multiple codesign identity installed, select the one you want to use and set its name in the [iOS Platform Configs>Codesign Identity] input field.")
+	}
+}
+
+func (builder FlutterBuilder) buildSequentially(cfg BuildConfig, out *RunOutput) error {
+	for _, spec := range cfg.specs {
+		spec.buildStartTime = time.Now()
+
+		fmt.Println()
+		log.Infof("Build " + spec.displayName)
+		finishBuild := out.timings.start("Build " + spec.displayName)
+		err := spec.Build()
+		finishBuild()
+		if err != nil {
+			warnBuildFailure(err, cfg.codesignIdentity)
+			if !cfg.continueOnError {
+				return &StepError{Phase: PhaseRun, Err: &BuildError{Platform: spec.displayName, Err: err}}
+			}
+			log.Errorf("Failed to build %s: %s", spec.displayName, err)
+			out.report.add(spec.displayName, platformBuildFailed, err)
+			continue
+		}
+		out.built = append(out.built, spec)
+	}
+	return nil
+}
+
+func (builder FlutterBuilder) buildConcurrently(cfg BuildConfig, out *RunOutput) error {
+	fmt.Println()
+	log.Infof("Build platforms concurrently")
+
+	specs := append([]BuildSpecification{}, cfg.specs...)
+	for i := range specs {
+		specs[i].buildStartTime = time.Now()
+	}
+
+	var succeeded []BuildSpecification
+	var failures []string
+	for _, result := range buildConcurrently(specs, cfg.parallelOutput) {
+		out.timings.add("Build "+result.spec.displayName, result.duration)
+		if result.err != nil {
+			warnBuildFailure(result.err, cfg.codesignIdentity)
+			failures = append(failures, fmt.Sprintf("%s: %s", result.spec.displayName, result.err))
+			out.report.add(result.spec.displayName, platformBuildFailed, result.err)
+			continue
+		}
+		succeeded = append(succeeded, result.spec)
+	}
+
+	// With all_succeeded, failed builds prevent exporting the succeeded ones, unless continuing on error
+	if len(failures) == 0 || cfg.parallelExport == ParallelExportPartial || cfg.continueOnError {
+		out.built = succeeded
+	}
+	if len(failures) > 0 && !cfg.continueOnError {
+		return newStepError(PhaseRun, "failed to build %s", strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+// prepareCodesign checks that the iOS codesign identity used by flutter is installed,
+// identity is stored in the Flutter settings if set.
+func (builder FlutterBuilder) prepareCodesign(identity string) error {
+	log.Printf(" Installed codesign identities:")
+	installedCertificates, err := builder.installedCodesignIdentities()
+	if err != nil {
+		return fmt.Errorf("failed to fetch installed codesign identities: %s", err)
+	}
+	for _, installed := range installedCertificates {
+		log.Printf(" - %s", installed)
+	}
+
+	if len(installedCertificates) == 0 {
+		return errors.New("no codesign identities installed")
+	}
+
+	flutterSettings, err := readFlutterSettings(builder.flutterSettingsPath)
+	if err != nil {
+		return err
+	}
+
+	if identity != "" {
+		log.Warnf(" Override codesign identity:")
+		log.Printf(" - Store: %s", identity)
+		if !sliceutil.IsStringInSlice(identity, installedCertificates) {
+			return fmt.Errorf("the selected identity \"%s\" is not installed on the system", identity)
+		}
+		flutterSettings[codesignField] = identity
+		newSettingsContent, err := json.MarshalIndent(flutterSettings, "", " ")
+		if err != nil {
+			return fmt.Errorf("failed to parse .flutter_settings file: %s", err)
+		}
+		if err := fileutil.WriteBytesToFile(builder.flutterSettingsPath, newSettingsContent); err != nil {
+			return fmt.Errorf("error while writing .flutter_settings file: %s", err)
+		}
+		log.Donef(" - Done")
+		return nil
+	}
+
+	log.Printf(" Stored Flutter codesign settings:")
+	storedIdentity, ok := flutterSettings[codesignField]
+	if !ok {
+		log.Printf(" - No codesign identity set")
+		return nil
+	}
+	log.Printf(" - %s", storedIdentity)
+	if !sliceutil.IsStringInSlice(storedIdentity, installedCertificates) {
+		return fmt.Errorf("identity \"%s\" is not installed on the system", storedIdentity)
+	}
+	return nil
+}
+
+// readFlutterSettings returns the content of the Flutter settings file, an empty map if it does not exist.
+func readFlutterSettings(pth string) (map[string]string, error) {
+	exists, err := pathutil.IsPathExists(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if %s exists: %s", pth, err)
+	}
+	if !exists {
+		return map[string]string{}, nil
+	}
+
+	content, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading %s: %s", pth, err)
+	}
+	var flutterSettings map[string]string
+	if err := json.Unmarshal(content, &flutterSettings); err != nil {
+		return nil, fmt.Errorf("failed to parse .flutter_settings file: %s", err)
+	}
+	if flutterSettings == nil {
+		flutterSettings = map[string]string{}
+	}
+	return flutterSettings, nil
+}
+
+// Export exports the app metadata, the artifacts of the builds in out and the cache paths.
+func (builder FlutterBuilder) Export(cfg BuildConfig, out RunOutput) error {
+	fmt.Println()
+	log.Infof("App metadata")
+
+	if err := exportPubspecMetadata(builder.exporter, cfg.pubspec); err != nil {
+		return &StepError{Phase: PhaseExport, Err: err}
+	}
+
+	if cfg.buildName != "" || cfg.buildNumber != "" {
+		fmt.Println()
+		log.Infof("Build version")
+
+		if err := exportBuildVersion(builder.exporter, cfg.buildName, cfg.buildNumber); err != nil {
+			return &StepError{Phase: PhaseExport, Err: err}
+		}
+	}
+
+	for _, spec := range out.built {
+		finishExport := out.timings.start("Export " + spec.displayName)
+		err := spec.ExportOutputs()
+		finishExport()
+		if err != nil {
+			if !cfg.continueOnError {
+				return &StepError{Phase: PhaseExport, Err: err}
+			}
+			log.Errorf("Failed to export %s: %s", spec.displayName, err)
+			out.report.add(spec.displayName, platformExportFailed, err)
+			continue
+		}
+		out.report.add(spec.displayName, platformSucceeded, nil)
+	}
+
+	if cfg.cacheLevel == "all" {
+		fmt.Println()
+		log.Infof("Collecting cache")
+
+		finishCache := out.timings.start("Cache")
+		builder.collectCache(cfg)
+		finishCache()
+	}
+
+	out.timings.print()
+
+	if cfg.continueOnError {
+		out.report.print()
+		if out.report.failed() {
+			return &StepError{Phase: out.report.phase(), Err: out.report.err()}
+		}
+	}
+	return nil
+}
+
+// collectCache exports the dependency paths to cache, failures are only logged.
+func (builder FlutterBuilder) collectCache(cfg BuildConfig) {
+	items := collectCacheItems(cfg.projectLocation, cfg.workspaceDir())
+	if err := commitCacheItems(builder.exporter, items); err != nil {
+		log.Warnf("Failed to collect cache, error: %s", err)
+	}
+}
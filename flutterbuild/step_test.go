@@ -0,0 +1,164 @@
+package flutterbuild
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlutterBuilder_ProcessConfig(t *testing.T) {
+	projectDir := createTestProject(t)
+	builder := NewFlutterBuilder(Dependencies{Runner: &fakeFlutterRunner{t: t}}, t.TempDir(), "/deploy")
+
+	t.Run("selected platforms", func(t *testing.T) {
+		cfg := testConfig(projectDir)
+		cfg.Platform = "android"
+		cfg.BuildNumberSource = BuildNumberSourceNone
+
+		buildConfig, err := builder.ProcessConfig(cfg)
+		require.NoError(t, err)
+		require.Len(t, buildConfig.specs, 1)
+		require.Equal(t, "Android app", buildConfig.specs[0].displayName)
+		require.Equal(t, "/deploy", buildConfig.specs[0].deployDir)
+		require.Equal(t, []string{"--build-name=1.2.3"}, buildConfig.specs[0].additionalArgs)
+	})
+
+	t.Run("dart define file values are not redacted from other arguments", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, "defines.env"), []byte("RETRIES=3\nDEBUG=true\n"), 0644))
+		cfg := testConfig(projectDir)
+		cfg.Platform = "android"
+		cfg.BuildNumberSource = BuildNumberSourceExplicit
+		cfg.BuildNumber = "13"
+		cfg.DartDefines = "API_KEY=secret-key"
+		cfg.DartDefineFromFile = "defines.env"
+
+		buildConfig, err := builder.ProcessConfig(cfg)
+		require.NoError(t, err)
+		spec := buildConfig.specs[0]
+		args, err := spec.buildArgs()
+		require.NoError(t, err)
+		require.Equal(t, `flutter "build" "apk" "--dart-define=API_KEY=[REDACTED]" "--dart-define-from-file=`+filepath.Join(projectDir, "defines.env")+`" "--build-name=1.2.3" "--build-number=13"`, spec.printableCommand(args))
+	})
+
+	t.Run("zero value optional inputs", func(t *testing.T) {
+		runner := &fakeFlutterRunner{t: t}
+		builder := NewFlutterBuilder(Dependencies{Runner: runner}, t.TempDir(), t.TempDir())
+
+		buildConfig, err := builder.ProcessConfig(Inputs{ProjectLocation: projectDir, Platform: "android", AndroidOutputType: OutputTypeAPK})
+		require.NoError(t, err)
+		require.Len(t, buildConfig.specs, 1)
+		require.Empty(t, buildConfig.specs[0].additionalArgs)
+
+		_, err = builder.Run(buildConfig)
+		require.NoError(t, err)
+		require.Equal(t, []string{"flutter build apk"}, runner.commands)
+	})
+
+	t.Run("codesign skipped", func(t *testing.T) {
+		cfg := testConfig(projectDir)
+		cfg.BuildNumberSource = BuildNumberSourceNone
+		cfg.IOSAdditionalParams = "--no-codesign"
+
+		buildConfig, err := builder.ProcessConfig(cfg)
+		require.NoError(t, err)
+		require.Equal(t, "--no-codesign parameter set", buildConfig.codesignSkipReason)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		cfg := testConfig(projectDir)
+		cfg.BuildNumberSource = BuildNumberSourceNone
+		cfg.SizeAnalysis = true
+		cfg.AndroidSplitPerABI = true
+		cfg.AndroidPrimaryABI = "arm64-v8a"
+
+		_, err := builder.ProcessConfig(cfg)
+		require.EqualError(t, err, "Process config: size analysis can not be used together with split APKs per ABI")
+	})
+}
+
+func TestFlutterBuilder_prepareCodesign(t *testing.T) {
+	installed := []string{"iPhone Distribution: Bitrise Sample (ABCDE12345)"}
+	builder := NewFlutterBuilder(Dependencies{
+		InstalledCodesignIdentities: func() ([]string, error) { return installed, nil },
+	}, t.TempDir(), "")
+
+	require.NoError(t, builder.prepareCodesign(""))
+
+	require.EqualError(t, builder.prepareCodesign("iPhone Developer: Other"), `the selected identity "iPhone Developer: Other" is not installed on the system`)
+
+	require.NoError(t, builder.prepareCodesign(installed[0]))
+	settings, err := readFlutterSettings(builder.flutterSettingsPath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{codesignField: installed[0]}, settings)
+
+	require.NoError(t, os.WriteFile(builder.flutterSettingsPath, []byte(`{"ios-signing-cert": "iPhone Developer: Other"}`), 0644))
+	require.EqualError(t, builder.prepareCodesign(""), `identity "iPhone Developer: Other" is not installed on the system`)
+}
+
+func TestFlutterBuilder_Run_buildError(t *testing.T) {
+	builder := NewFlutterBuilder(Dependencies{Runner: &fakeFlutterRunner{t: t}}, t.TempDir(), t.TempDir())
+
+	cfg := testConfig(createTestProject(t))
+	cfg.Platform = "both"
+	cfg.BuildNumberSource = BuildNumberSourceNone
+	cfg.IOSOutputType = OutputTypeIOSApp
+	cfg.AndroidOutputType = "web"
+
+	buildConfig, err := builder.ProcessConfig(cfg)
+	require.NoError(t, err)
+
+	out, err := builder.Run(buildConfig)
+	require.EqualError(t, err, "Run: failed to build Android app: unexpected build target: web")
+	require.Len(t, out.built, 1)
+	require.Equal(t, "iOS app", out.built[0].displayName)
+
+	var buildErr *BuildError
+	require.True(t, errors.As(err, &buildErr))
+	require.Equal(t, "Android app", buildErr.Platform)
+}
+
+func TestFlutterBuilder_Run_buildConcurrently(t *testing.T) {
+	tests := []struct {
+		name           string
+		parallelExport ParallelExport
+		wantBuilt      []string
+	}{
+		{
+			name:           "all_succeeded exports nothing if a build fails",
+			parallelExport: ParallelExportAllSucceeded,
+		},
+		{
+			name:           "partial exports the succeeded builds",
+			parallelExport: ParallelExportPartial,
+			wantBuilt:      []string{"iOS app"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewFlutterBuilder(Dependencies{Runner: &fakeFlutterRunner{t: t}}, t.TempDir(), t.TempDir())
+
+			cfg := testConfig(createTestProject(t))
+			cfg.BuildNumberSource = BuildNumberSourceNone
+			cfg.IOSOutputType = OutputTypeIOSApp
+			cfg.AndroidOutputType = "web"
+			cfg.ParallelBuilds = true
+			cfg.ParallelOutput = ParallelOutputBuffered
+			cfg.ParallelExport = tt.parallelExport
+
+			buildConfig, err := builder.ProcessConfig(cfg)
+			require.NoError(t, err)
+
+			out, err := builder.Run(buildConfig)
+			require.EqualError(t, err, "Run: failed to build Android app: unexpected build target: web")
+
+			var built []string
+			for _, spec := range out.built {
+				built = append(built, spec.displayName)
+			}
+			require.Equal(t, tt.wantBuilt, built)
+		})
+	}
+}
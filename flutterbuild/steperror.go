@@ -0,0 +1,47 @@
+package flutterbuild
+
+import "fmt"
+
+// Phase is a phase of the FlutterBuilder.
+type Phase string
+
+const (
+	// PhaseProcessConfig is FlutterBuilder.ProcessConfig
+	PhaseProcessConfig Phase = "Process config"
+	// PhaseRun is FlutterBuilder.Run
+	PhaseRun Phase = "Run"
+	// PhaseExport is FlutterBuilder.Export
+	PhaseExport Phase = "Export outputs"
+)
+
+// StepError is returned by the FlutterBuilder phases, Phase tells which phase failed.
+type StepError struct {
+	Phase Phase
+	Err   error
+}
+
+func newStepError(phase Phase, format string, args ...interface{}) *StepError {
+	return &StepError{Phase: phase, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Phase, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// BuildError is the failed build of a platform.
+type BuildError struct {
+	Platform string
+	Err      error
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("failed to build %s: %s", e.Platform, e.Err)
+}
+
+func (e *BuildError) Unwrap() error {
+	return e.Err
+}
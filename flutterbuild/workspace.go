@@ -0,0 +1,105 @@
+package flutterbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+type workspaceType string
+
+const (
+	workspaceTypeMelos workspaceType = "melos"
+	workspaceTypePub   workspaceType = "pub"
+)
+
+type workspace struct {
+	workspaceType workspaceType
+	rootDir       string
+}
+
+// findWorkspace looks for a melos.yaml or a pubspec.yaml with a `workspace:` section
+// in the project directory and in its parent directories, up to the git repository root or stopDir.
+// Returns nil if the project is not part of a workspace.
+func findWorkspace(projectDir, stopDir string) (*workspace, error) {
+	dir := projectDir
+	for {
+		ws, err := workspaceInDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		if ws != nil {
+			return ws, nil
+		}
+
+		if dir == stopDir {
+			return nil, nil
+		}
+		if isGitRoot, err := pathutil.IsPathExists(filepath.Join(dir, ".git")); err != nil {
+			return nil, err
+		} else if isGitRoot {
+			return nil, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+func workspaceInDir(dir string) (*workspace, error) {
+	if exist, err := pathutil.IsPathExists(filepath.Join(dir, "melos.yaml")); err != nil {
+		return nil, err
+	} else if exist {
+		return &workspace{workspaceType: workspaceTypeMelos, rootDir: dir}, nil
+	}
+
+	pubspecPth := filepath.Join(dir, "pubspec.yaml")
+	if exist, err := pathutil.IsPathExists(pubspecPth); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, nil
+	}
+
+	pubspec, err := readPubspec(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pubspec.Workspace) == 0 {
+		return nil, nil
+	}
+
+	// Melos 7+ keeps its configuration in the `melos:` section of the workspace root pubspec.yaml
+	if !pubspec.Melos.IsZero() {
+		return &workspace{workspaceType: workspaceTypeMelos, rootDir: dir}, nil
+	}
+	return &workspace{workspaceType: workspaceTypePub, rootDir: dir}, nil
+}
+
+// bootstrapCommand returns the command installing the dependencies of the workspace, melos is looked up with the runner.
+func (ws workspace) bootstrapCommand(runner CommandRunner) Command {
+	cmd := Command{Name: "melos", Args: []string{"bootstrap"}, Dir: ws.rootDir, Stdout: os.Stdout, Stderr: os.Stderr}
+	if ws.workspaceType == workspaceTypePub {
+		cmd.Name, cmd.Args = "flutter", []string{"pub", "get"}
+	} else if _, err := runner.LookPath("melos"); err != nil {
+		log.Debugf("melos executable not found on $PATH, falling back to the globally activated package")
+		cmd.Name, cmd.Args = "dart", []string{"pub", "global", "run", "melos", "bootstrap"}
+	}
+	return cmd
+}
+
+func (ws workspace) bootstrap(runner CommandRunner) error {
+	cmd := ws.bootstrapCommand(runner)
+
+	fmt.Println()
+	log.Donef("$ %s", cmd.printableCommandArgs())
+	fmt.Println()
+
+	return runner.Run(cmd)
+}
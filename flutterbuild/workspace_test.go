@@ -0,0 +1,126 @@
+package flutterbuild
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findWorkspace(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		stopDir  string
+		want     *workspace
+		wantRoot string
+	}{
+		{
+			name: "no workspace",
+			files: map[string]string{
+				"app/pubspec.yaml": "name: app\n",
+			},
+			want: nil,
+		},
+		{
+			name: "melos.yaml in parent directory",
+			files: map[string]string{
+				"melos.yaml":       "name: mono\npackages:\n  - app\n",
+				"app/pubspec.yaml": "name: app\n",
+			},
+			want:     &workspace{workspaceType: workspaceTypeMelos},
+			wantRoot: ".",
+		},
+		{
+			name: "pub workspace",
+			files: map[string]string{
+				"pubspec.yaml":     "name: mono\nworkspace:\n  - app\n",
+				"app/pubspec.yaml": "name: app\nresolution: workspace\n",
+			},
+			want:     &workspace{workspaceType: workspaceTypePub},
+			wantRoot: ".",
+		},
+		{
+			name: "melos configured in the workspace pubspec.yaml",
+			files: map[string]string{
+				"pubspec.yaml":     "name: mono\nworkspace:\n  - app\nmelos:\n  scripts: {}\n",
+				"app/pubspec.yaml": "name: app\nresolution: workspace\n",
+			},
+			want:     &workspace{workspaceType: workspaceTypeMelos},
+			wantRoot: ".",
+		},
+		{
+			name: "melos.yaml above the git repository root",
+			files: map[string]string{
+				"melos.yaml":       "name: mono\npackages:\n  - app\n",
+				"app/.git/HEAD":    "ref: refs/heads/main\n",
+				"app/pubspec.yaml": "name: app\n",
+			},
+			want: nil,
+		},
+		{
+			name: "melos.yaml above the working directory",
+			files: map[string]string{
+				"melos.yaml":       "name: mono\npackages:\n  - app\n",
+				"app/pubspec.yaml": "name: app\n",
+			},
+			stopDir: "app",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			for pth, content := range tt.files {
+				pth = filepath.Join(rootDir, pth)
+				require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0755))
+				require.NoError(t, os.WriteFile(pth, []byte(content), 0644))
+			}
+
+			got, err := findWorkspace(filepath.Join(rootDir, "app"), filepath.Join(rootDir, tt.stopDir))
+			require.NoError(t, err)
+
+			if tt.want == nil {
+				require.Nil(t, got)
+				return
+			}
+			require.NotNil(t, got)
+			require.Equal(t, tt.want.workspaceType, got.workspaceType)
+			require.Equal(t, filepath.Join(rootDir, tt.wantRoot), got.rootDir)
+		})
+	}
+}
+
+func Test_workspace_bootstrapCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		ws          workspace
+		executables []string
+		want        string
+	}{
+		{
+			name: "pub workspace",
+			ws:   workspace{workspaceType: workspaceTypePub},
+			want: "flutter pub get",
+		},
+		{
+			name:        "melos on $PATH",
+			ws:          workspace{workspaceType: workspaceTypeMelos},
+			executables: []string{"melos"},
+			want:        "melos bootstrap",
+		},
+		{
+			name: "globally activated melos",
+			ws:   workspace{workspaceType: workspaceTypeMelos},
+			want: "dart pub global run melos bootstrap",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.ws.bootstrapCommand(&fakeFlutterRunner{t: t, executables: tt.executables})
+			require.Equal(t, tt.want, strings.Join(append([]string{cmd.Name}, cmd.Args...), " "))
+		})
+	}
+}